@@ -0,0 +1,309 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// activeSetQP solves the strictly convex quadratic program
+//  min 1/2 x^T H x + f^T x  s.t.  l <= A x <= u
+// by the dual active-set method of Goldfarb and Idnani. Starting from the
+// unconstrained minimum, all equalities are added with multipliers of either
+// sign and then the most violated inequality is added in every step while the
+// multipliers of the inequalities stay non-negative. The factors J = L^-T Q and R of
+// the QR decomposition of L^-1 N of the active normals N are updated by
+// Givens rotations. The active set of the previous solve is added first.
+type activeSetQP struct {
+	h             *mat.SymDense
+	a             *mat.Dense
+	maxIterations int
+	tolerance     float64
+
+	x          *mat.VecDense // Solution
+	iterations int
+	chol       mat.Cholesky
+	linv       *mat.Dense // L^-T with H = L L^T
+	previous   map[int]bool
+}
+
+// newActiveSetQP returns a solver for the problem with the fixed H and A
+func newActiveSetQP(h *mat.SymDense, a *mat.Dense, maxIterations int, tolerance float64) (*activeSetQP, error) {
+	n := h.Symmetric()
+	q := &activeSetQP{
+		h:             h,
+		a:             a,
+		maxIterations: maxIterations,
+		tolerance:     tolerance,
+		x:             mat.NewVecDense(n, nil),
+		previous:      make(map[int]bool),
+	}
+	if ok := q.chol.Factorize(h); !ok {
+		return nil, errors.New("QP: Hessian should be positive definite for the active-set method")
+	}
+	var u mat.TriDense
+	q.chol.UTo(&u)
+	var uinv mat.TriDense
+	if err := uinv.InverseTri(&u); err != nil {
+		return nil, err
+	}
+	q.linv = mat.DenseCopyOf(&uinv)
+	return q, nil
+}
+
+// warmStart has no effect on the dual method, which always starts at the
+// unconstrained minimum; the previous active set is used instead
+func (q *activeSetQP) warmStart(x *mat.VecDense) {}
+
+// solve solves the problem for the linear term f and the bounds l and u
+func (q *activeSetQP) solve(f *mat.VecDense, l, u []float64) (*mat.VecDense, error) {
+	n := q.h.Symmetric()
+	rows, _ := q.a.Dims()
+	q.iterations = 0
+
+	// constraints c^T x >= b: 2 i for the lower and 2 i + 1 for the upper
+	// bound of row i; equalities l = u are stored as lower bounds
+	normal := func(k int, dst []float64) {
+		mat.Row(dst, k/2, q.a)
+		if k%2 == 1 {
+			for j := range dst {
+				dst[j] = -dst[j]
+			}
+		}
+	}
+	bound := func(k int) float64 {
+		if k%2 == 1 {
+			return -u[k/2]
+		}
+		return l[k/2]
+	}
+	equality := func(k int) bool {
+		return k%2 == 0 && l[k/2] == u[k/2]
+	}
+
+	// unconstrained minimum x = -H^-1 f
+	var negf mat.VecDense
+	negf.ScaleVec(-1, f)
+	if err := q.chol.SolveVecTo(q.x, &negf); err != nil {
+		return nil, err
+	}
+	x := q.x.RawVector().Data
+
+	j := mat.DenseCopyOf(q.linv)
+	r := mat.NewDense(n, n, nil)
+	var active []int
+	var mult []float64
+
+	np := make([]float64, n)
+	d := make([]float64, n)
+	z := make([]float64, n)
+	rv := make([]float64, n)
+	row := make([]float64, n)
+
+	isActive := make([]bool, 2*rows)
+	slack := func(k int) float64 {
+		normal(k, row)
+		return floats.Dot(row, x) - bound(k)
+	}
+	scale := func(k int) float64 {
+		return q.tolerance * math.Max(1, math.Abs(bound(k)))
+	}
+
+	// directions computes d = J^T n+, the primal step z = J2 d2 and the
+	// dual step r = R^-1 d1 for the na active constraints
+	directions := func(na int) {
+		for i := 0; i < n; i++ {
+			d[i] = floats.Dot(mat.Col(row, i, j), np)
+		}
+		for i := 0; i < n; i++ {
+			z[i] = 0
+			for k := na; k < n; k++ {
+				z[i] += j.At(i, k) * d[k]
+			}
+		}
+		for i := na - 1; i >= 0; i-- {
+			sum := d[i]
+			for k := i + 1; k < na; k++ {
+				sum -= r.At(i, k) * rv[k]
+			}
+			rv[i] = sum / r.At(i, i)
+		}
+	}
+
+	// all equalities are added first with multipliers of either sign
+	for k := 0; k < 2*rows; k += 2 {
+		if !equality(k) {
+			continue
+		}
+		q.iterations++
+		na := len(active)
+		normal(k, np)
+		directions(na)
+		zn := floats.Dot(z, np)
+		if math.Sqrt(floats.Dot(z, z)) <= 1e-12 || zn <= 0 {
+			// linearly dependent on the active equalities
+			if math.Abs(slack(k)) > scale(k) {
+				q.storeActive(active)
+				return q.x, errors.New("QP: constraints are infeasible")
+			}
+			continue
+		}
+		t := -slack(k) / zn
+		for i := range x {
+			x[i] += t * z[i]
+		}
+		for i := 0; i < na; i++ {
+			mult[i] -= t * rv[i]
+		}
+		mult = append(mult, t)
+		addConstraint(j, r, d, na)
+		active = append(active, k)
+		isActive[k] = true
+	}
+
+	for {
+		// choose the violated inequality to add: previously active
+		// constraints first, then the most violated constraint
+		p, worst := -1, 0.0
+		for pass := 0; pass < 2 && p < 0; pass++ {
+			for k := 0; k < 2*rows; k++ {
+				if isActive[k] || l[k/2] == u[k/2] || math.IsInf(bound(k), -1) {
+					continue
+				}
+				if pass == 0 && !q.previous[k] {
+					continue
+				}
+				if v := slack(k); v < -scale(k) && v < worst {
+					p, worst = k, v
+				}
+			}
+		}
+		if p < 0 {
+			break
+		}
+		normal(p, np)
+		bp := bound(p)
+		mult = append(mult, 0)
+
+		for {
+			q.iterations++
+			if q.iterations > q.maxIterations {
+				q.storeActive(active)
+				return q.x, ErrNotConverged
+			}
+			na := len(active)
+			directions(na)
+
+			// partial step: largest step keeping the multipliers of the
+			// inequalities non-negative
+			t1, drop := math.Inf(1), -1
+			for i := 0; i < na; i++ {
+				if rv[i] > 0 && !equality(active[i]) {
+					if t := mult[i] / rv[i]; t < t1 {
+						t1, drop = t, i
+					}
+				}
+			}
+
+			// full step: step in the primal space to satisfy the constraint
+			t2 := math.Inf(1)
+			if zn := floats.Dot(z, np); math.Sqrt(floats.Dot(z, z)) > 1e-12 && zn > 0 {
+				t2 = -(floats.Dot(np, x) - bp) / zn
+			}
+			t := math.Min(t1, t2)
+			if math.IsInf(t, 1) {
+				q.storeActive(active)
+				return q.x, errors.New("QP: constraints are infeasible")
+			}
+
+			for i := 0; i < na; i++ {
+				mult[i] -= t * rv[i]
+			}
+			mult[na] += t
+			if !math.IsInf(t2, 1) {
+				for i := range x {
+					x[i] += t * z[i]
+				}
+			}
+			if t == t2 {
+				addConstraint(j, r, d, na)
+				active = append(active, p)
+				isActive[p] = true
+				break
+			}
+
+			// drop the blocking constraint and retry
+			isActive[active[drop]] = false
+			dropConstraint(j, r, drop, na)
+			active = append(active[:drop], active[drop+1:]...)
+			mult = append(mult[:drop], mult[drop+1:]...)
+		}
+	}
+	q.storeActive(active)
+	return q.x, nil
+}
+
+// storeActive remembers the active inequalities for the next solve
+func (q *activeSetQP) storeActive(active []int) {
+	q.previous = make(map[int]bool, len(active))
+	for _, k := range active {
+		q.previous[k] = true
+	}
+}
+
+// addConstraint rotates d = J^T n+ such that d[q+1:] = 0 and appends d[:q+1]
+// as column q to R
+func addConstraint(j, r *mat.Dense, d []float64, q int) {
+	n, _ := j.Dims()
+	for k := n - 1; k > q; k-- {
+		if d[k] == 0 {
+			continue
+		}
+		h := math.Hypot(d[k-1], d[k])
+		c, s := d[k-1]/h, d[k]/h
+		d[k-1], d[k] = h, 0
+		rotateColumns(j, k-1, k, c, s)
+	}
+	for i := 0; i <= q; i++ {
+		r.Set(i, q, d[i])
+	}
+}
+
+// dropConstraint removes column k of the q active columns of R and restores
+// the triangular form by Givens rotations, which are applied to J
+func dropConstraint(j, r *mat.Dense, k, q int) {
+	for col := k; col < q-1; col++ {
+		for i := 0; i <= col+1; i++ {
+			r.Set(i, col, r.At(i, col+1))
+		}
+	}
+	for i := 0; i < q; i++ {
+		r.Set(i, q-1, 0)
+	}
+	for i := k; i < q-1; i++ {
+		a, b := r.At(i, i), r.At(i+1, i)
+		if b == 0 {
+			continue
+		}
+		h := math.Hypot(a, b)
+		c, s := a/h, b/h
+		for col := i; col < q-1; col++ {
+			ri, ri1 := r.At(i, col), r.At(i+1, col)
+			r.Set(i, col, c*ri+s*ri1)
+			r.Set(i+1, col, -s*ri+c*ri1)
+		}
+		rotateColumns(j, i, i+1, c, s)
+	}
+}
+
+// rotateColumns applies the Givens rotation (c, s) to the columns i and k of m
+func rotateColumns(m *mat.Dense, i, k int, c, s float64) {
+	rows, _ := m.Dims()
+	for row := 0; row < rows; row++ {
+		a, b := m.At(row, i), m.At(row, k)
+		m.Set(row, i, c*a+s*b)
+		m.Set(row, k, -s*a+c*b)
+	}
+}
@@ -0,0 +1,211 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//ActuatorLimit describes which limit of an actuator channel was active
+type ActuatorLimit int
+
+const (
+	//LimitMin is the lower bound of the input
+	LimitMin ActuatorLimit = iota
+	//LimitMax is the upper bound of the input
+	LimitMax
+	//LimitRate is the maximum change of the input per sample
+	LimitRate
+)
+
+//String returns the name of the limit
+func (l ActuatorLimit) String() string {
+	switch l {
+	case LimitMin:
+		return "min"
+	case LimitMax:
+		return "max"
+	case LimitRate:
+		return "rate"
+	}
+	return "unknown"
+}
+
+//SaturationEvent records that the limit of a channel was active at a step,
+//i.e. that the applied input differs from the commanded input. Applied is the
+//input after all stages.
+type SaturationEvent struct {
+	Step      int
+	Channel   int
+	Limit     ActuatorLimit
+	Commanded float64
+	Applied   float64
+}
+
+//Actuator models the nonlinearities between the commanded and the applied
+//inputs of a plant. The command u passes per channel
+// 	the dead band:    |u| <= DeadBand gives 0
+// 	the rate limit:   |u - u(k-1)| <= RateLimit per sample
+// 	the saturation:   Min <= u <= Max
+// 	the quantization: u is rounded to a multiple of Quantization within the bounds
+//The quantized input stays within the saturation and the rate limit; if no
+//multiple of Quantization lies in between, the binding limit is applied.
+//Nil slices and zero entries disable a stage, single bounds can be disabled
+//with -Inf or +Inf. Every saturation and rate limitation is appended to Log,
+//including a limit that constrains the quantization.
+type Actuator struct {
+	Min, Max     []float64
+	RateLimit    []float64
+	DeadBand     []float64
+	Quantization []float64
+	Log          []SaturationEvent
+
+	last *mat.VecDense // Last applied input, nil before the first step
+	step int
+}
+
+//Reset sets the last applied input, which is the reference of the rate limit
+//at the next step, and clears the step counter and the log.
+//If u is nil, the first input is not rate limited.
+func (a *Actuator) Reset(u *mat.VecDense) {
+	a.last = nil
+	if u != nil {
+		a.last = mat.VecDenseCopyOf(u)
+	}
+	a.step = 0
+	a.Log = nil
+}
+
+//Apply returns the input applied by the actuator for the commanded input u
+//and advances the step counter.
+func (a *Actuator) Apply(u *mat.VecDense) (*mat.VecDense, error) {
+	m := u.Len()
+	if err := a.check(m); err != nil {
+		return nil, err
+	}
+	if a.last != nil && a.last.Len() != m {
+		return nil, errors.New("Actuator: last input should match the number of inputs")
+	}
+
+	res := mat.NewVecDense(m, nil)
+	for i := 0; i < m; i++ {
+		cmd := u.AtVec(i)
+		v := cmd
+		events := len(a.Log)
+
+		if a.DeadBand != nil && math.Abs(v) <= a.DeadBand[i] {
+			v = 0
+		}
+
+		// interval of the rate limit
+		rlo, rhi := math.Inf(-1), math.Inf(1)
+		if a.RateLimit != nil && a.RateLimit[i] > 0 && a.last != nil {
+			prev, lim := a.last.AtVec(i), a.RateLimit[i]
+			rlo, rhi = prev-lim, prev+lim
+			if v < rlo || v > rhi {
+				v = math.Max(rlo, math.Min(v, rhi))
+				a.record(i, LimitRate, cmd, v)
+			}
+		}
+
+		lo, hi := math.Inf(-1), math.Inf(1)
+		if a.Min != nil {
+			lo = a.Min[i]
+		}
+		if a.Max != nil {
+			hi = a.Max[i]
+		}
+		if v < lo {
+			v = lo
+			a.record(i, LimitMin, cmd, v)
+		} else if v > hi {
+			v = hi
+			a.record(i, LimitMax, cmd, v)
+		}
+
+		if a.Quantization != nil && a.Quantization[i] > 0 {
+			// the quantized input stays within the bounds and the rate
+			// limit; without a multiple of q in between, the binding limit
+			// is applied
+			q := a.Quantization[i]
+			low, lowLimit := lo, LimitMin
+			if rlo > lo {
+				low, lowLimit = rlo, LimitRate
+			}
+			high, highLimit := hi, LimitMax
+			if rhi < hi {
+				high, highLimit = rhi, LimitRate
+			}
+			quantized := math.Round(v/q) * q
+			if quantized > high {
+				quantized = math.Floor(high/q+1e-9) * q
+				if quantized < low {
+					quantized = high
+				}
+				quantized = math.Min(quantized, high)
+				a.record(i, highLimit, cmd, quantized)
+			} else if quantized < low {
+				quantized = math.Ceil(low/q-1e-9) * q
+				if quantized > high {
+					quantized = low
+				}
+				quantized = math.Max(quantized, low)
+				a.record(i, lowLimit, cmd, quantized)
+			}
+			v = quantized
+		}
+
+		// the events of the channel record the final input
+		for j := events; j < len(a.Log); j++ {
+			a.Log[j].Applied = v
+		}
+		res.SetVec(i, v)
+	}
+
+	a.last = mat.VecDenseCopyOf(res)
+	a.step++
+	return res, nil
+}
+
+//Saturated returns true if any limit of the channel was active at the step.
+func (a *Actuator) Saturated(step, channel int) bool {
+	for _, e := range a.Log {
+		if e.Step == step && e.Channel == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// record appends a saturation event of the current step to the log unless the
+// last event has the same limit
+func (a *Actuator) record(channel int, limit ActuatorLimit, commanded, applied float64) {
+	if n := len(a.Log); n > 0 {
+		if e := a.Log[n-1]; e.Step == a.step && e.Channel == channel && e.Limit == limit {
+			return
+		}
+	}
+	a.Log = append(a.Log, SaturationEvent{
+		Step:      a.step,
+		Channel:   channel,
+		Limit:     limit,
+		Commanded: commanded,
+		Applied:   applied,
+	})
+}
+
+// check checks that the limits match the number of inputs m
+func (a *Actuator) check(m int) error {
+	for _, limits := range [][]float64{a.Min, a.Max, a.RateLimit, a.DeadBand, a.Quantization} {
+		if limits != nil && len(limits) != m {
+			return errors.New("Actuator: limits should match the number of inputs")
+		}
+	}
+	for i := 0; a.Min != nil && a.Max != nil && i < m; i++ {
+		if a.Min[i] > a.Max[i] {
+			return errors.New("Actuator: Min should not exceed Max")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package lti
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestActuator(t *testing.T) {
+	act := &Actuator{
+		Min:          []float64{-1, math.Inf(-1)},
+		Max:          []float64{1, math.Inf(1)},
+		RateLimit:    []float64{0, 0.5},
+		DeadBand:     []float64{0.1, 0},
+		Quantization: []float64{0.25, 0},
+	}
+	act.Reset(mat.NewVecDense(2, nil))
+
+	tests := []struct {
+		cmd, applied []float64
+	}{
+		{[]float64{0.05, 2}, []float64{0, 0.5}},     // dead band, rate limit
+		{[]float64{1.7, 0.7}, []float64{1, 0.7}},    // saturation
+		{[]float64{0.6, -0.2}, []float64{0.5, 0.2}}, // quantization, rate limit
+		{[]float64{-3, 0.2}, []float64{-1, 0.2}},    // saturation
+	}
+	for k, test := range tests {
+		u, err := act.Apply(mat.NewVecDense(2, test.cmd))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mat.EqualApprox(u, mat.NewVecDense(2, test.applied), 1e-12) {
+			t.Error("Actuator returned wrong input at step", k, ":", u.RawVector().Data, test.applied)
+		}
+	}
+
+	expected := []SaturationEvent{
+		{Step: 0, Channel: 1, Limit: LimitRate, Commanded: 2, Applied: 0.5},
+		{Step: 1, Channel: 0, Limit: LimitMax, Commanded: 1.7, Applied: 1},
+		{Step: 2, Channel: 1, Limit: LimitRate, Commanded: -0.2, Applied: 0.2},
+		{Step: 3, Channel: 0, Limit: LimitMin, Commanded: -3, Applied: -1},
+	}
+	if len(act.Log) != len(expected) {
+		t.Fatal("Actuator logged wrong number of events:", act.Log)
+	}
+	for i, e := range expected {
+		got := act.Log[i]
+		if got.Step != e.Step || got.Channel != e.Channel || got.Limit != e.Limit ||
+			got.Commanded != e.Commanded || math.Abs(got.Applied-e.Applied) > 1e-12 {
+			t.Error("Actuator logged wrong event:", act.Log[i], e)
+		}
+	}
+	if !act.Saturated(1, 0) || act.Saturated(1, 1) {
+		t.Error("Saturated returned wrong channels")
+	}
+
+	act.Reset(nil)
+	if len(act.Log) != 0 {
+		t.Error("Reset should clear the log")
+	}
+	if _, err := act.Apply(mat.NewVecDense(3, nil)); err == nil {
+		t.Error("expected error for wrong number of inputs")
+	}
+}
+
+func TestActuatorQuantizationLimits(t *testing.T) {
+	tests := []struct {
+		act     *Actuator
+		last    float64
+		cmd     float64
+		applied float64
+		limit   ActuatorLimit
+	}{
+		// rounding 0.2 up to 0.25 would exceed the rate limit
+		{&Actuator{RateLimit: []float64{0.1}, Quantization: []float64{0.25}}, 0.1, 1, 0, LimitRate},
+		// no multiple of 0.25 lies within the bounds
+		{&Actuator{Min: []float64{0.05}, Max: []float64{0.15}, Quantization: []float64{0.25}}, 0, 0.14, 0.15, LimitMax},
+		{&Actuator{Min: []float64{0.05}, Max: []float64{0.15}, Quantization: []float64{0.25}}, 0, 0.1, 0.05, LimitMin},
+	}
+	for k, test := range tests {
+		test.act.Reset(mat.NewVecDense(1, []float64{test.last}))
+		u, err := test.act.Apply(mat.NewVecDense(1, []float64{test.cmd}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(u.AtVec(0)-test.applied) > 1e-12 {
+			t.Error("Actuator returned wrong input in test", k, ":", u.AtVec(0), test.applied)
+		}
+		if len(test.act.Log) != 1 || test.act.Log[0].Limit != test.limit || math.Abs(test.act.Log[0].Applied-test.applied) > 1e-12 {
+			t.Error("Actuator logged wrong events in test", k, ":", test.act.Log)
+		}
+	}
+}
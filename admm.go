@@ -0,0 +1,366 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// settings of the ADMM solver
+const (
+	admmSigma         = 1e-6
+	admmAlpha         = 1.6
+	admmCheckEvery    = 10
+	admmAdaptEvery    = 50
+	admmScalingPasses = 15
+)
+
+// admmQP solves the quadratic program
+//  min 1/2 x^T H x + f^T x  s.t.  l <= A x <= u
+// by the alternating direction method of multipliers (ADMM) in the form of OSQP
+// with Ruiz equilibration, over-relaxation and adaptive step size rho.
+// The scaled iterates are kept between calls to warm start the next solve.
+type admmQP struct {
+	h             *mat.SymDense
+	a             *mat.Dense
+	maxIterations int
+	tolerance     float64
+
+	x          *mat.VecDense // Solution
+	iterations int
+	free       quadraticMinimizer // Minimizer for unconstrained problems
+
+	// scaled problem c D H D, c D f, E A D, E l, E u
+	hs      *mat.SymDense
+	as      *mat.Dense
+	d, e    []float64
+	c       float64
+	fs      *mat.VecDense
+	ls, us  []float64
+	rho     float64
+	xs, zs  *mat.VecDense
+	ys      *mat.VecDense
+	warm    bool
+	kkt     mat.Cholesky // Hs + sigma I + rho As^T As
+	kktRho  float64
+	scratch *mat.VecDense
+}
+
+// newADMMQP returns a solver for the problem with the fixed H and A
+func newADMMQP(h *mat.SymDense, a *mat.Dense, maxIterations int, tolerance float64) *admmQP {
+	n := h.Symmetric()
+	rows, _ := a.Dims()
+	q := &admmQP{
+		h:             h,
+		a:             a,
+		maxIterations: maxIterations,
+		tolerance:     tolerance,
+		x:             mat.NewVecDense(n, nil),
+		free:          quadraticMinimizer{h: h},
+		rho:           0.1,
+		xs:            mat.NewVecDense(n, nil),
+		zs:            mat.NewVecDense(rows, nil),
+		ys:            mat.NewVecDense(rows, nil),
+		fs:            mat.NewVecDense(n, nil),
+		ls:            make([]float64, rows),
+		us:            make([]float64, rows),
+		scratch:       mat.NewVecDense(rows, nil),
+	}
+	q.scale()
+	return q
+}
+
+// scale equilibrates the KKT matrix [H A^T; A 0] by the Ruiz method
+func (q *admmQP) scale() {
+	n := q.h.Symmetric()
+	rows, _ := q.a.Dims()
+	hs := mat.NewSymDense(n, nil)
+	hs.CopySym(q.h)
+	var as mat.Dense
+	as.CloneFrom(q.a)
+	d, e := ones(n), ones(rows)
+
+	clamp := func(norm float64) float64 {
+		if norm < 1e-4 {
+			return 1
+		}
+		return 1 / math.Sqrt(math.Min(norm, 1e4))
+	}
+	for pass := 0; pass < admmScalingPasses; pass++ {
+		dd, de := make([]float64, n), make([]float64, rows)
+		for j := 0; j < n; j++ {
+			norm := 0.0
+			for i := 0; i < n; i++ {
+				norm = math.Max(norm, math.Abs(hs.At(i, j)))
+			}
+			for i := 0; i < rows; i++ {
+				norm = math.Max(norm, math.Abs(as.At(i, j)))
+			}
+			dd[j] = clamp(norm)
+		}
+		for i := 0; i < rows; i++ {
+			norm := 0.0
+			for j := 0; j < n; j++ {
+				norm = math.Max(norm, math.Abs(as.At(i, j)))
+			}
+			de[i] = clamp(norm)
+		}
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				hs.SetSym(i, j, dd[i]*hs.At(i, j)*dd[j])
+			}
+			d[i] *= dd[i]
+		}
+		for i := 0; i < rows; i++ {
+			for j := 0; j < n; j++ {
+				as.Set(i, j, de[i]*as.At(i, j)*dd[j])
+			}
+			e[i] *= de[i]
+		}
+	}
+
+	// cost scaling by the mean column norm of H
+	mean := 0.0
+	for j := 0; j < n; j++ {
+		norm := 0.0
+		for i := 0; i < n; i++ {
+			norm = math.Max(norm, math.Abs(hs.At(i, j)))
+		}
+		mean += norm / float64(n)
+	}
+	q.c = 1
+	if mean > 1e-4 {
+		q.c = 1 / math.Min(mean, 1e4)
+	}
+	hs.ScaleSym(q.c, hs)
+
+	q.hs, q.as, q.d, q.e = hs, &as, d, e
+}
+
+// warmStart sets the initial iterate x for the next solve
+func (q *admmQP) warmStart(x *mat.VecDense) {
+	for i := 0; i < x.Len(); i++ {
+		q.xs.SetVec(i, x.AtVec(i)/q.d[i])
+	}
+	q.warm = true
+}
+
+// solve solves the problem for the linear term f and the bounds l and u
+func (q *admmQP) solve(f *mat.VecDense, l, u []float64) (*mat.VecDense, error) {
+	n := q.h.Symmetric()
+	rows, _ := q.a.Dims()
+	q.iterations = 0
+	if unbounded(l, u) {
+		// unconstrained: H x = -f
+		if err := q.free.minimize(f, q.x); err != nil {
+			return nil, err
+		}
+		q.warmStart(q.x)
+		return q.x, nil
+	}
+
+	// scaled linear term and bounds
+	for i := 0; i < n; i++ {
+		q.fs.SetVec(i, q.c*q.d[i]*f.AtVec(i))
+	}
+	for i := 0; i < rows; i++ {
+		q.ls[i], q.us[i] = q.e[i]*l[i], q.e[i]*u[i]
+	}
+	if q.warm {
+		q.zs.MulVec(q.as, q.xs)
+		for i := 0; i < rows; i++ {
+			q.zs.SetVec(i, math.Min(math.Max(q.zs.AtVec(i), q.ls[i]), q.us[i]))
+		}
+		q.warm = false
+	}
+
+	if q.kktRho != q.rho {
+		if err := q.factorize(); err != nil {
+			return nil, err
+		}
+	}
+
+	err := ErrNotConverged
+	var rhs, xt, ax, tmp mat.VecDense
+	for k := 1; k <= q.maxIterations; k++ {
+		q.iterations = k
+
+		// x~ = (H + sigma I + rho A^T A)^-1 (sigma x - f + A^T (rho z - y))
+		tmp.ScaleVec(q.rho, q.zs)
+		tmp.SubVec(&tmp, q.ys)
+		rhs.MulVec(q.as.T(), &tmp)
+		rhs.AddScaledVec(&rhs, admmSigma, q.xs)
+		rhs.SubVec(&rhs, q.fs)
+		if err := q.kkt.SolveVecTo(&xt, &rhs); err != nil {
+			return nil, err
+		}
+
+		// relaxation and projection onto [l, u]
+		ax.MulVec(q.as, &xt)
+		for i := 0; i < rows; i++ {
+			axr := admmAlpha*ax.AtVec(i) + (1-admmAlpha)*q.zs.AtVec(i)
+			zn := math.Min(math.Max(axr+q.ys.AtVec(i)/q.rho, q.ls[i]), q.us[i])
+			q.ys.SetVec(i, q.ys.AtVec(i)+q.rho*(axr-zn))
+			q.scratch.SetVec(i, zn)
+		}
+		q.zs.CopyVec(q.scratch)
+		q.xs.ScaleVec(1-admmAlpha, q.xs)
+		q.xs.AddScaledVec(q.xs, admmAlpha, &xt)
+
+		if k%admmCheckEvery != 0 {
+			continue
+		}
+		prim, dual, primScale, dualScale := q.residuals()
+		if prim <= q.tolerance*(1+primScale) && dual <= q.tolerance*(1+dualScale) {
+			err = nil
+			break
+		}
+
+		if k%admmAdaptEvery != 0 {
+			continue
+		}
+		if q.polish() {
+			err = nil
+			break
+		}
+
+		// adapt rho to balance the primal and dual residuals
+		if prim > 0 && dual > 0 {
+			ratio := math.Sqrt((prim / math.Max(primScale, 1e-12)) / (dual / math.Max(dualScale, 1e-12)))
+			if newRho := math.Min(math.Max(q.rho*ratio, 1e-6), 1e6); newRho > 5*q.rho || newRho < q.rho/5 {
+				q.rho = newRho
+				if err := q.factorize(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// unscaled solution x = D xs
+	for i := 0; i < n; i++ {
+		q.x.SetVec(i, q.d[i]*q.xs.AtVec(i))
+	}
+	return q.x, err
+}
+
+// factorize factorizes Hs + sigma I + rho As^T As
+func (q *admmQP) factorize() error {
+	n := q.hs.Symmetric()
+	var kkt mat.SymDense
+	kkt.SymOuterK(q.rho, q.as.T())
+	kkt.AddSym(&kkt, q.hs)
+	for i := 0; i < n; i++ {
+		kkt.SetSym(i, i, kkt.At(i, i)+admmSigma)
+	}
+	if ok := q.kkt.Factorize(&kkt); !ok {
+		return errors.New("QP: KKT matrix is not positive definite")
+	}
+	q.kktRho = q.rho
+	return nil
+}
+
+// polish guesses the active constraints from the current iterate and solves
+// the equality constrained problem
+//  [H   A_a^T] [x]   [-f ]
+//  [A_a   0  ] [y] = [b_a]
+// The solution is accepted if it is feasible and the multipliers have the
+// correct signs, which resolves the slow tail convergence of ADMM.
+func (q *admmQP) polish() bool {
+	n := q.hs.Symmetric()
+	rows, _ := q.as.Dims()
+	var active []int
+	var bound []float64
+	for i := 0; i < rows; i++ {
+		z, y := q.zs.AtVec(i), q.ys.AtVec(i)
+		if z-q.ls[i] < -y {
+			active, bound = append(active, i), append(bound, q.ls[i])
+		} else if q.us[i]-z < y {
+			active, bound = append(active, i), append(bound, q.us[i])
+		}
+	}
+
+	na := len(active)
+	kkt := mat.NewDense(n+na, n+na, nil)
+	kkt.Slice(0, n, 0, n).(*mat.Dense).Copy(q.hs)
+	rhs := mat.NewVecDense(n+na, nil)
+	for i := 0; i < n; i++ {
+		rhs.SetVec(i, -q.fs.AtVec(i))
+	}
+	for k, i := range active {
+		for j := 0; j < n; j++ {
+			kkt.Set(n+k, j, q.as.At(i, j))
+			kkt.Set(j, n+k, q.as.At(i, j))
+		}
+		// small regularization for dependent active constraints
+		kkt.Set(n+k, n+k, -1e-12)
+		rhs.SetVec(n+k, bound[k])
+	}
+	var sol mat.VecDense
+	if err := sol.SolveVec(kkt, rhs); err != nil {
+		return false
+	}
+
+	x := mat.VecDenseCopyOf(sol.SliceVec(0, n))
+	var ax mat.VecDense
+	ax.MulVec(q.as, x)
+	y := mat.NewVecDense(rows, nil)
+	for k, i := range active {
+		y.SetVec(i, sol.AtVec(n+k))
+	}
+	tol := q.tolerance * (1 + mat.Norm(&ax, math.Inf(1)))
+	dualTol := q.tolerance * (1 + mat.Norm(y, math.Inf(1)))
+	for i := 0; i < rows; i++ {
+		v, yi := ax.AtVec(i), y.AtVec(i)
+		if v < q.ls[i]-tol || v > q.us[i]+tol {
+			return false
+		}
+		if (yi < -dualTol && v > q.ls[i]+tol) || (yi > dualTol && v < q.us[i]-tol) {
+			return false
+		}
+	}
+
+	q.xs.CopyVec(x)
+	q.ys.CopyVec(y)
+	for i := 0; i < rows; i++ {
+		q.zs.SetVec(i, math.Min(math.Max(ax.AtVec(i), q.ls[i]), q.us[i]))
+	}
+	return true
+}
+
+// residuals returns the primal residual |A x - z|, the dual residual
+// |H x + f + A^T y| of the scaled problem and their scales
+func (q *admmQP) residuals() (float64, float64, float64, float64) {
+	var ax, r, hx, aty, d mat.VecDense
+	ax.MulVec(q.as, q.xs)
+	r.SubVec(&ax, q.zs)
+	hx.MulVec(q.hs, q.xs)
+	aty.MulVec(q.as.T(), q.ys)
+	d.AddVec(&hx, q.fs)
+	d.AddVec(&d, &aty)
+
+	prim := mat.Norm(&r, math.Inf(1))
+	dual := mat.Norm(&d, math.Inf(1))
+	primScale := math.Max(mat.Norm(&ax, math.Inf(1)), mat.Norm(q.zs, math.Inf(1)))
+	dualScale := math.Max(mat.Norm(&hx, math.Inf(1)), math.Max(mat.Norm(&aty, math.Inf(1)), mat.Norm(q.fs, math.Inf(1))))
+	return prim, dual, primScale, dualScale
+}
+
+// unbounded returns true if all bounds are infinite
+func unbounded(l, u []float64) bool {
+	for i := range l {
+		if !math.IsInf(l[i], -1) || !math.IsInf(u[i], 1) {
+			return false
+		}
+	}
+	return true
+}
+
+// ones returns a slice of n ones
+func ones(n int) []float64 {
+	v := make([]float64, n)
+	for i := range v {
+		v[i] = 1
+	}
+	return v
+}
@@ -0,0 +1,258 @@
+package lti
+
+import (
+	"errors"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//StateIndices maps the states of an augmented system onto the states
+//of the original system (Plant) and the added states (Added).
+type StateIndices struct {
+	Plant []int
+	Added []int
+}
+
+//AugmentIntegrators augments the system with the integrals xi' = r - y_i of
+//the tracking errors of the given outputs. The augmented system has the
+//inputs [u; r] with one reference r for every integrated output, the
+//outputs y and the states [x; xi]. Systems with delays return ErrDelays,
+//see Pade.
+func (s *System) AugmentIntegrators(outputs []int) (*System, *StateIndices, error) {
+	r, idx, err := augmentIntegrators(s.realization(), outputs, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.system()
+	return sys, idx, err
+}
+
+//AugmentInputDisturbances augments the system with constant disturbance
+//states d' = 0 which act on the given inputs, x' = A x + B u + B_i d.
+//The delays are kept, as delayed constant disturbances are constant.
+func (s *System) AugmentInputDisturbances(inputs []int) (*System, *StateIndices, error) {
+	r, idx, err := augmentInputDisturbances(s.realization(), inputs, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.system()
+	return sys, idx, err
+}
+
+//AugmentOutputDisturbances augments the system with constant disturbance
+//states d' = 0 which are added to the given outputs, y_i = C_i x + D_i u + d.
+//The delays are kept, see AugmentInputDisturbances.
+func (s *System) AugmentOutputDisturbances(outputs []int) (*System, *StateIndices, error) {
+	r, idx, err := augmentOutputDisturbances(s.realization(), outputs, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.system()
+	return sys, idx, err
+}
+
+//AugmentIntegrators augments the discrete system with the summed tracking
+//errors xi(k+1) = xi(k) + Dt * (r(k) - y_i(k)) of the given outputs,
+//see System.AugmentIntegrators.
+func (d *Discrete) AugmentIntegrators(outputs []int) (*Discrete, *StateIndices, error) {
+	if d.Dt <= 0 {
+		return nil, nil, errors.New("sample time Dt should be positive")
+	}
+	r, idx, err := augmentIntegrators(d.realization(), outputs, d.Dt)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.discrete(d.Dt)
+	return sys, idx, err
+}
+
+//AugmentInputDisturbances augments the discrete system with constant
+//disturbance states d(k+1) = d(k) which act on the given inputs,
+//see System.AugmentInputDisturbances.
+func (d *Discrete) AugmentInputDisturbances(inputs []int) (*Discrete, *StateIndices, error) {
+	r, idx, err := augmentInputDisturbances(d.realization(), inputs, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.discrete(d.Dt)
+	return sys, idx, err
+}
+
+//AugmentOutputDisturbances augments the discrete system with constant
+//disturbance states d(k+1) = d(k) which are added to the given outputs,
+//see System.AugmentOutputDisturbances.
+func (d *Discrete) AugmentOutputDisturbances(outputs []int) (*Discrete, *StateIndices, error) {
+	r, idx, err := augmentOutputDisturbances(d.realization(), outputs, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.discrete(d.Dt)
+	return sys, idx, err
+}
+
+// augmentIntegrators adds the integrators of the tracking errors of the
+// outputs; dt is zero for time-continuous and the sample time for discrete systems
+func augmentIntegrators(r realization, outputs []int, dt float64) (realization, *StateIndices, error) {
+	n, m, p := r.dims()
+	k := len(outputs)
+	if err := checkChannels(outputs, p, "output"); err != nil {
+		return realization{}, nil, err
+	}
+	if r.hasDelays() {
+		return realization{}, nil, ErrDelays
+	}
+	sel, err := subsystem(r, outputs, nil)
+	if err != nil {
+		return realization{}, nil, err
+	}
+
+	// continuous: xi' = r - C_i x - D_i u
+	// discrete:   xi(k+1) = xi(k) + dt * (r - C_i x - D_i u)
+	scale := -1.0
+	if dt > 0 {
+		scale = -dt
+	}
+	a := mat.NewDense(n+k, n+k, nil)
+	setBlock(a, 0, 0, r.a)
+	setBlock(a, n, 0, scaled(scale, sel.c))
+	b := mat.NewDense(n+k, m+k, nil)
+	setBlock(b, 0, 0, r.b)
+	setBlock(b, n, 0, scaled(scale, sel.d))
+	for i := 0; i < k; i++ {
+		if dt > 0 {
+			a.Set(n+i, n+i, 1)
+			b.Set(n+i, m+i, dt)
+		} else {
+			b.Set(n+i, m+i, 1)
+		}
+	}
+	c := mat.NewDense(p, n+k, nil)
+	setBlock(c, 0, 0, r.c)
+	d := mat.NewDense(p, m+k, nil)
+	setBlock(d, 0, 0, r.d)
+
+	res := realization{
+		a: a, b: b, c: c, d: d,
+		inputs:  concatSignals([][]Signal{r.inputs, addedSignals(r.outputs, outputs, "_ref")}, []int{m, k}),
+		outputs: copySignals(r.outputs),
+		states:  concatSignals([][]Signal{r.states, addedSignals(r.outputs, outputs, "_int")}, []int{n, k}),
+	}
+	return res, &StateIndices{Plant: sequence(n), Added: rangeOf(n, n+k)}, nil
+}
+
+// augmentInputDisturbances adds constant disturbances acting on the inputs
+func augmentInputDisturbances(r realization, inputs []int, discrete bool) (realization, *StateIndices, error) {
+	n, m, p := r.dims()
+	k := len(inputs)
+	if err := checkChannels(inputs, m, "input"); err != nil {
+		return realization{}, nil, err
+	}
+	sel, err := subsystem(r, nil, inputs)
+	if err != nil {
+		return realization{}, nil, err
+	}
+
+	a := mat.NewDense(n+k, n+k, nil)
+	setBlock(a, 0, 0, r.a)
+	setBlock(a, 0, n, sel.b)
+	b := mat.NewDense(n+k, m, nil)
+	setBlock(b, 0, 0, r.b)
+	c := mat.NewDense(p, n+k, nil)
+	setBlock(c, 0, 0, r.c)
+	setBlock(c, 0, n, sel.d)
+	if discrete {
+		setIdentity(a, n, n, k)
+	}
+	var d mat.Dense
+	d.CloneFrom(r.d)
+
+	res := realization{
+		a: a, b: b, c: c, d: &d,
+		inputs:      copySignals(r.inputs),
+		outputs:     copySignals(r.outputs),
+		states:      concatSignals([][]Signal{r.states, addedSignals(r.inputs, inputs, "_dist")}, []int{n, k}),
+		inputDelay:  copyDelays(r.inputDelay),
+		outputDelay: copyDelays(r.outputDelay),
+	}
+	return res, &StateIndices{Plant: sequence(n), Added: rangeOf(n, n+k)}, nil
+}
+
+// augmentOutputDisturbances adds constant disturbances to the outputs
+func augmentOutputDisturbances(r realization, outputs []int, discrete bool) (realization, *StateIndices, error) {
+	n, m, p := r.dims()
+	k := len(outputs)
+	if err := checkChannels(outputs, p, "output"); err != nil {
+		return realization{}, nil, err
+	}
+
+	a := mat.NewDense(n+k, n+k, nil)
+	setBlock(a, 0, 0, r.a)
+	b := mat.NewDense(n+k, m, nil)
+	setBlock(b, 0, 0, r.b)
+	c := mat.NewDense(p, n+k, nil)
+	setBlock(c, 0, 0, r.c)
+	for i, out := range outputs {
+		c.Set(out, n+i, 1)
+	}
+	if discrete {
+		setIdentity(a, n, n, k)
+	}
+	var d mat.Dense
+	d.CloneFrom(r.d)
+
+	res := realization{
+		a: a, b: b, c: c, d: &d,
+		inputs:      copySignals(r.inputs),
+		outputs:     copySignals(r.outputs),
+		states:      concatSignals([][]Signal{r.states, addedSignals(r.outputs, outputs, "_dist")}, []int{n, k}),
+		inputDelay:  copyDelays(r.inputDelay),
+		outputDelay: copyDelays(r.outputDelay),
+	}
+	return res, &StateIndices{Plant: sequence(n), Added: rangeOf(n, n+k)}, nil
+}
+
+// checkChannels checks that the channels are unique and within [0, n)
+func checkChannels(channels []int, n int, kind string) error {
+	if len(channels) == 0 {
+		return errors.New("at least one " + kind + " should be selected")
+	}
+	used := make(map[int]bool)
+	for _, ch := range channels {
+		if ch < 0 || ch >= n {
+			return errors.New(kind + " out of range")
+		}
+		if used[ch] {
+			return errors.New(kind + " selected more than once")
+		}
+		used[ch] = true
+	}
+	return nil
+}
+
+// addedSignals returns the signals of the selected channels with the
+// suffix appended to their names, or nil if the channels are unnamed
+func addedSignals(signals []Signal, channels []int, suffix string) []Signal {
+	if signals == nil {
+		return nil
+	}
+	res := selectSignals(signals, channels)
+	for i := range res {
+		if res[i].Name != "" {
+			res[i].Name += suffix
+		}
+	}
+	return res
+}
+
+// setBlock copies src into dst at (i, j)
+func setBlock(dst *mat.Dense, i, j int, src mat.Matrix) {
+	r, c := src.Dims()
+	dst.Slice(i, i+r, j, j+c).(*mat.Dense).Copy(src)
+}
+
+// scaled returns f * m
+func scaled(f float64, m mat.Matrix) *mat.Dense {
+	var res mat.Dense
+	res.Scale(f, m)
+	return &res
+}
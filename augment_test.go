@@ -0,0 +1,84 @@
+package lti
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestAugment(t *testing.T) {
+	g := newTestFirstOrder(2, 1, 0.5)
+	g.Inputs = []Signal{{Name: "u"}}
+	g.Outputs = []Signal{{Name: "y", Unit: "m"}}
+
+	integ, idx, err := g.AugmentIntegrators([]int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.Equal(integ.A, mat.NewDense(2, 2, []float64{-1, 0, -2, 0})) ||
+		!mat.Equal(integ.B, mat.NewDense(2, 2, []float64{1, 0, -0.5, 1})) ||
+		!mat.Equal(integ.C, mat.NewDense(1, 2, []float64{2, 0})) ||
+		!mat.Equal(integ.D, mat.NewDense(1, 2, []float64{0.5, 0})) {
+		fmt.Println(integ.A, integ.B, integ.C, integ.D)
+		t.Error("AugmentIntegrators returned wrong system")
+	}
+	if len(idx.Plant) != 1 || idx.Added[0] != 1 {
+		t.Error("AugmentIntegrators returned wrong indices:", idx)
+	}
+	if integ.Inputs[1] != (Signal{Name: "y_ref", Unit: "m"}) || integ.States[1].Name != "y_int" {
+		t.Error("AugmentIntegrators returned wrong signals:", integ.Inputs, integ.States)
+	}
+
+	in, _, err := g.AugmentInputDisturbances([]int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.Equal(in.A, mat.NewDense(2, 2, []float64{-1, 1, 0, 0})) ||
+		!mat.Equal(in.C, mat.NewDense(1, 2, []float64{2, 0.5})) {
+		fmt.Println(in.A, in.C)
+		t.Error("AugmentInputDisturbances returned wrong system")
+	}
+
+	out, _, err := g.AugmentOutputDisturbances([]int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.Equal(out.A, mat.NewDense(2, 2, []float64{-1, 0, 0, 0})) ||
+		!mat.Equal(out.C, mat.NewDense(1, 2, []float64{2, 1})) {
+		fmt.Println(out.A, out.C)
+		t.Error("AugmentOutputDisturbances returned wrong system")
+	}
+
+	if _, _, err := g.AugmentIntegrators([]int{1}); err == nil {
+		t.Error("expected error for output out of range")
+	}
+}
+
+func TestAugmentDiscrete(t *testing.T) {
+	dt := 0.1
+	disc, err := newTestFirstOrder(2, 1, 0).Discretize(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	integ, _, err := disc.AugmentIntegrators([]int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ad := disc.Ad.At(0, 0)
+	if !mat.EqualApprox(integ.Ad, mat.NewDense(2, 2, []float64{ad, 0, -2 * dt, 1}), 1e-15) ||
+		integ.Bd.At(1, 1) != dt {
+		fmt.Println(integ.Ad, integ.Bd)
+		t.Error("discrete AugmentIntegrators returned wrong system")
+	}
+
+	in, _, err := disc.AugmentInputDisturbances([]int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Ad.At(1, 1) != 1 || in.Ad.At(0, 1) != disc.Bd.At(0, 0) || in.Dt != dt {
+		fmt.Println(in.Ad)
+		t.Error("discrete AugmentInputDisturbances returned wrong system")
+	}
+}
@@ -0,0 +1,278 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// hankelTolerance is the relative tolerance below which Hankel singular
+// values are treated as zero
+const hankelTolerance = 1e-12
+
+//Balance returns the balanced realization of the stable and minimal system,
+//whose controllability and observability Gramians are equal and diagonal,
+//and the Hankel singular values on the diagonal in decreasing order.
+//Input and output delays are kept; the balancing and the reductions apply
+//to the delay-free part, which does not change the H-infinity error bound.
+func (s *System) Balance() (*System, []float64, error) {
+	r, hsv, err := balanced(s.realization(), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.system()
+	return sys, hsv, err
+}
+
+//BalancedTruncation returns the reduced system of the given order by
+//truncating the states of the balanced realization with the smallest
+//Hankel singular values. The second value is the guaranteed bound
+//2 * (sum of the truncated Hankel singular values) of the H-infinity
+//norm of the error between the system and the reduced system.
+func (s *System) BalancedTruncation(order int) (*System, float64, error) {
+	r, bound, err := reduce(s.realization(), order, false, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	sys, err := r.system()
+	return sys, bound, err
+}
+
+//SingularPerturbation returns the reduced system of the given order by
+//residualizing the states of the balanced realization with the smallest
+//Hankel singular values, i.e. by setting their derivatives to zero.
+//Unlike BalancedTruncation, the DC gain is preserved. The second value is
+//the guaranteed H-infinity error bound, see BalancedTruncation.
+func (s *System) SingularPerturbation(order int) (*System, float64, error) {
+	r, bound, err := reduce(s.realization(), order, false, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	sys, err := r.system()
+	return sys, bound, err
+}
+
+//Balance returns the balanced realization of the stable and minimal
+//discrete system and its Hankel singular values, see System.Balance.
+func (d *Discrete) Balance() (*Discrete, []float64, error) {
+	r, hsv, err := balanced(d.realization(), true)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.discrete(d.Dt)
+	return sys, hsv, err
+}
+
+//BalancedTruncation returns the reduced discrete system of the given order
+//and the H-infinity error bound, see System.BalancedTruncation.
+func (d *Discrete) BalancedTruncation(order int) (*Discrete, float64, error) {
+	r, bound, err := reduce(d.realization(), order, true, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	sys, err := r.discrete(d.Dt)
+	return sys, bound, err
+}
+
+//SingularPerturbation returns the reduced discrete system of the given order,
+//whose residualized states are set to their steady state x(k+1) = x(k),
+//and the H-infinity error bound, see System.SingularPerturbation.
+func (d *Discrete) SingularPerturbation(order int) (*Discrete, float64, error) {
+	r, bound, err := reduce(d.realization(), order, true, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	sys, err := r.discrete(d.Dt)
+	return sys, bound, err
+}
+
+// balanced returns the balanced realization of the minimal realization r
+func balanced(r realization, discrete bool) (realization, []float64, error) {
+	n, _, _ := r.dims()
+	t, tinv, hsv, rank, err := balancingTransformation(r, discrete)
+	if err != nil {
+		return realization{}, nil, err
+	}
+	if rank < n {
+		return realization{}, nil, errors.New("Balance: system is not minimal, see Minimal")
+	}
+	return project(r, t, tinv), hsv, nil
+}
+
+// reduce returns the balanced truncation or singular perturbation
+// approximation of the given order and the error bound
+func reduce(r realization, order int, discrete, perturb bool) (realization, float64, error) {
+	t, tinv, hsv, rank, err := balancingTransformation(r, discrete)
+	if err != nil {
+		return realization{}, 0, err
+	}
+	if order < 1 || order > rank {
+		return realization{}, 0, errors.New("order should be between 1 and the number of non-zero Hankel singular values")
+	}
+	bound := 0.0
+	for _, v := range hsv[order:] {
+		bound += 2 * v
+	}
+
+	if !perturb {
+		rows, _ := t.Dims()
+		return project(r, t.Slice(0, rows, 0, order).(*mat.Dense), tinv.Slice(0, order, 0, rows).(*mat.Dense)), bound, nil
+	}
+
+	res, err := residualize(project(r, t, tinv), order, discrete)
+	return res, bound, err
+}
+
+// residualize keeps the first order states of r and replaces the remaining
+// states 2 by their steady state, i.e.
+//  continuous: x2 = -A22^-1 (A21 x1 + B2 u)
+//  discrete:   x2 = (I - A22)^-1 (A21 x1 + B2 u)
+func residualize(r realization, order int, discrete bool) (realization, error) {
+	n, m, p := r.dims()
+	if order == n {
+		return r, nil
+	}
+	a11 := r.a.Slice(0, order, 0, order)
+	a12 := r.a.Slice(0, order, order, n)
+	a21 := r.a.Slice(order, n, 0, order)
+	b1 := r.b.Slice(0, order, 0, m)
+	b2 := r.b.Slice(order, n, 0, m)
+	c1 := r.c.Slice(0, p, 0, order)
+	c2 := r.c.Slice(0, p, order, n)
+
+	var e mat.Dense
+	e.Scale(-1, r.a.Slice(order, n, order, n))
+	if discrete {
+		for i := 0; i < n-order; i++ {
+			e.Set(i, i, e.At(i, i)+1)
+		}
+	}
+	var ea21, eb2 mat.Dense
+	if err := ea21.Solve(&e, a21); err != nil {
+		return realization{}, errors.New("residualized states have no steady state")
+	}
+	if err := eb2.Solve(&e, b2); err != nil {
+		return realization{}, errors.New("residualized states have no steady state")
+	}
+
+	var a, b, c, d, tmp mat.Dense
+	tmp.Mul(a12, &ea21)
+	a.Add(a11, &tmp)
+	tmp.Reset()
+	tmp.Mul(a12, &eb2)
+	b.Add(b1, &tmp)
+	tmp.Reset()
+	tmp.Mul(c2, &ea21)
+	c.Add(c1, &tmp)
+	tmp.Reset()
+	tmp.Mul(c2, &eb2)
+	d.Add(r.d, &tmp)
+
+	return realization{
+		a: &a, b: &b, c: &c, d: &d,
+		inputs:      copySignals(r.inputs),
+		outputs:     copySignals(r.outputs),
+		inputDelay:  copyDelays(r.inputDelay),
+		outputDelay: copyDelays(r.outputDelay),
+	}, nil
+}
+
+// balancingTransformation returns the square-root balancing transformation
+// x = T xb, xb = Tinv x of the states with non-zero Hankel singular values.
+//
+// With the Gramians Wc = Lc Lc^T, Wo = Lo Lo^T and the SVD Lo^T Lc = U S V^T,
+// T = Lc V S^-1/2 and Tinv = S^-1/2 U^T Lo^T.
+func balancingTransformation(r realization, discrete bool) (*mat.Dense, *mat.Dense, []float64, int, error) {
+	n, _, _ := r.dims()
+	var at mat.Dense
+	at.CloneFrom(r.a.T())
+
+	var wc, wo *mat.SymDense
+	var err error
+	if discrete {
+		if wc, err = DiscreteLyapunov(r.a, outerProduct(r.b)); err != nil {
+			return nil, nil, nil, 0, err
+		}
+		if wo, err = DiscreteLyapunov(&at, innerProduct(r.c)); err != nil {
+			return nil, nil, nil, 0, err
+		}
+	} else {
+		if wc, err = Lyapunov(r.a, outerProduct(r.b)); err != nil {
+			return nil, nil, nil, 0, err
+		}
+		if wo, err = Lyapunov(&at, innerProduct(r.c)); err != nil {
+			return nil, nil, nil, 0, err
+		}
+	}
+
+	// upper factors with W = S^T S, i.e. L = S^T
+	sc, err := sqrtFactor(wc)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+	so, err := sqrtFactor(wo)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+
+	// Lo^T Lc = So Sc^T
+	var h mat.Dense
+	h.Mul(so, sc.T())
+	var svd mat.SVD
+	if ok := svd.Factorize(&h, mat.SVDThin); !ok {
+		return nil, nil, nil, 0, errors.New("Balance: singular value decomposition failed")
+	}
+	hsv := svd.Values(nil)
+	var u, v mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+
+	rank := 0
+	for _, sv := range hsv {
+		if sv > hankelTolerance*math.Max(hsv[0], 1e-300) {
+			rank++
+		}
+	}
+	if rank == 0 {
+		return nil, nil, nil, 0, errors.New("Balance: all Hankel singular values are zero")
+	}
+
+	t := mat.NewDense(n, rank, nil)
+	tinv := mat.NewDense(rank, n, nil)
+	var lcv, utlo mat.Dense
+	lcv.Mul(sc.T(), &v)
+	utlo.Mul(u.T(), so)
+	for k := 0; k < rank; k++ {
+		f := 1 / math.Sqrt(hsv[k])
+		for i := 0; i < n; i++ {
+			t.Set(i, k, f*lcv.At(i, k))
+			tinv.Set(k, i, f*utlo.At(k, i))
+		}
+	}
+	return t, tinv, hsv, rank, nil
+}
+
+// project returns the realization Tinv A T, Tinv B, C T, D
+func project(r realization, t, tinv *mat.Dense) realization {
+	var at, a, b, c, d mat.Dense
+	at.Mul(r.a, t)
+	a.Mul(tinv, &at)
+	b.Mul(tinv, r.b)
+	c.Mul(r.c, t)
+	d.CloneFrom(r.d)
+	return realization{
+		a: &a, b: &b, c: &c, d: &d,
+		inputs:      copySignals(r.inputs),
+		outputs:     copySignals(r.outputs),
+		inputDelay:  copyDelays(r.inputDelay),
+		outputDelay: copyDelays(r.outputDelay),
+	}
+}
+
+// innerProduct returns C^T * C
+func innerProduct(c *mat.Dense) *mat.SymDense {
+	var ctc mat.SymDense
+	ctc.SymOuterK(1, c.T())
+	return &ctc
+}
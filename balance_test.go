@@ -0,0 +1,132 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// newTestFourthOrder returns 1/(s+1) + 0.5/(s+3) + 0.1/(s+10) + 0.01/(s+50)
+func newTestFourthOrder(t *testing.T) *System {
+	sys := newTestFirstOrder(1, 1, 0)
+	for _, pole := range [][2]float64{{0.5, 3}, {0.1, 10}, {0.01, 50}} {
+		var err error
+		sys, err = sys.Parallel(newTestFirstOrder(pole[0], pole[1], 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sys
+}
+
+// errorNorm returns the H-infinity norm of g1 - g2
+func errorNorm(t *testing.T, g1, g2 *System) float64 {
+	neg := *g2
+	var c, d mat.Dense
+	c.Scale(-1, g2.C)
+	d.Scale(-1, g2.D)
+	neg.C, neg.D = &c, &d
+	diff, err := g1.Parallel(&neg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	norm, _, err := diff.HinfNorm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return norm
+}
+
+func TestBalance(t *testing.T) {
+	sys := newTestFourthOrder(t)
+	bal, hsv, err := sys.Balance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 1; k < len(hsv); k++ {
+		if hsv[k] > hsv[k-1] {
+			t.Error("Hankel singular values should be decreasing:", hsv)
+		}
+	}
+
+	// both Gramians equal diag(hsv)
+	wc, err := Lyapunov(bal.A, outerProduct(bal.B))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var at mat.Dense
+	at.CloneFrom(bal.A.T())
+	wo, err := Lyapunov(&at, innerProduct(bal.C))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := mat.NewDiagDense(len(hsv), hsv)
+	if !mat.EqualApprox(wc, expected, 1e-9) || !mat.EqualApprox(wo, expected, 1e-9) {
+		fmt.Println("Wc:", mat.Formatted(wc), "Wo:", mat.Formatted(wo))
+		t.Error("balanced realization has wrong Gramians")
+	}
+
+	// a non-minimal system cannot be balanced
+	twice, err := sys.Append(sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := twice.Subsystem([]int{0}, []int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := sum.Balance(); err == nil {
+		t.Error("expected error for non-minimal system")
+	}
+}
+
+func TestBalancedReduction(t *testing.T) {
+	sys := newTestFourthOrder(t)
+	for _, residualize := range []bool{false, true} {
+		reduce := sys.BalancedTruncation
+		if residualize {
+			reduce = sys.SingularPerturbation
+		}
+		red, bound, err := reduce(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := red.A.Dims(); n != 2 {
+			t.Error("wrong order of reduced system:", n)
+		}
+		if e := errorNorm(t, sys, red); e > bound*(1+1e-9) || e <= 0 {
+			t.Error("error", e, "violates the bound", bound)
+		}
+		if residualize {
+			if g, e := sisoAt(t, red, 0), sisoAt(t, sys, 0); math.Abs(real(g-e)) > 1e-10 {
+				t.Error("SingularPerturbation should preserve the DC gain:", g, e)
+			}
+		}
+	}
+
+	disc, err := sys.Discretize(0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dred, dbound, err := disc.SingularPerturbation(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := sisoAt(t, dred, 0), sisoAt(t, disc, 0); math.Abs(real(g-e)) > 1e-10 {
+		t.Error("discrete SingularPerturbation should preserve the DC gain:", g, e)
+	}
+	dtrunc, _, err := disc.BalancedTruncation(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := 2.0
+	if diff := sisoAt(t, dtrunc, w) - sisoAt(t, disc, w); math.Hypot(real(diff), imag(diff)) > dbound {
+		t.Error("discrete BalancedTruncation violates the error bound")
+	}
+
+	if _, _, err := sys.BalancedTruncation(5); err == nil {
+		t.Error("expected error for order above the number of states")
+	}
+}
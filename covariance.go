@@ -1,11 +1,27 @@
 package lti
 
-import "gonum.org/v1/gonum/mat"
+import (
+	"errors"
+
+	"gonum.org/v1/gonum/mat"
+)
 
 //Covariance contains a discretized matrix to predict the next covariance matrix
-// according to p(k+1) = Md * p(k) * Md^T
+// according to p(k+1) = Md * p(k) * Md^T + G * Q * G^T
+//
+// The parameters are:
+// 	Md: Discretized system matrix
+// 	G:  Noise input matrix (identity if nil)
+// 	Q:  Noise spectral density (no noise if nil)
+//
 type Covariance struct {
 	Md *mat.Dense
+	G  *mat.Dense
+	Q  mat.Symmetric
+
+	mp, mpmt, gq, gqgt mat.Dense // Workspace for PredictTo
+	mdt, gt            mat.Transpose
+	p                  mat.SymDense
 }
 
 //NewCovariance creates a new covariance struct which
@@ -14,9 +30,43 @@ func NewCovariance(md *mat.Dense) *Covariance {
 	return &Covariance{Md: md}
 }
 
+//NewCovarianceWithNoise creates a new covariance struct with the discretized
+//matrix Md, the noise input matrix G and the noise spectral density Q.
+//If G is nil, the noise enters directly and Q must match the dimension of Md.
+func NewCovarianceWithNoise(md, g *mat.Dense, q mat.Symmetric) (*Covariance, error) {
+	// Md (n x n)
+	r, c := md.Dims()
+	if r != c {
+		return nil, errors.New("Md should be squared")
+	}
+
+	if q == nil {
+		return nil, errors.New("Q should not be nil")
+	}
+	nq := q.Symmetric()
+
+	// G (n x q)
+	if g != nil {
+		gr, gc := g.Dims()
+		if gr != r {
+			return nil, errors.New("G row should be equal to Md row dim")
+		}
+		if gc != nq {
+			return nil, errors.New("G col should be equal to Q dim")
+		}
+	} else if nq != r {
+		return nil, errors.New("Q dim should be equal to Md row dim when G is nil")
+	}
+
+	return &Covariance{Md: md, G: g, Q: q}, nil
+}
+
 //Predict propagates the covariance p(k) to p(k+1)
 //according to p(k+1) = Md * p(k) * Md^T;
 //additionally noise is added if not nil
+//
+//Deprecated: Predict ignores G and Q and needs the scratch matrices pmt and
+//mpmt. Use PredictSym or PredictTo instead.
 func (c *Covariance) Predict(p *mat.Dense, noise *mat.Dense, pmt, mpmt *mat.Dense) *mat.Dense {
 	// p(k+1) = m * p(k) * m^T + noise
 	pmt.Mul(p, c.Md.T())
@@ -28,3 +78,56 @@ func (c *Covariance) Predict(p *mat.Dense, noise *mat.Dense, pmt, mpmt *mat.Dens
 
 	return mpmt
 }
+
+//PredictSym propagates the covariance p(k) to p(k+1)
+//according to p(k+1) = Md * p(k) * Md^T + G * Q * G^T.
+//The returned matrix is owned by c and is overwritten by the next call
+//to PredictSym; it does not allocate once the workspace has been sized.
+func (c *Covariance) PredictSym(p mat.Symmetric) *mat.SymDense {
+	c.PredictTo(&c.p, p)
+	return &c.p
+}
+
+//PredictTo propagates the covariance p(k) and stores p(k+1) in dst.
+//The result is symmetrized to suppress round-off asymmetry.
+//dst may be p, in which case the covariance is updated in place. An empty
+//dst is resized; PredictTo panics if a non-empty dst does not match Md.
+func (c *Covariance) PredictTo(dst *mat.SymDense, p mat.Symmetric) {
+	// the transposes are kept in c so that no interface values escape
+	c.mdt.Matrix = c.Md
+
+	// p(k+1) = m * p(k) * m^T
+	c.mp.Mul(c.Md, p)
+	c.mpmt.Mul(&c.mp, &c.mdt)
+
+	// + G * Q * G^T
+	if c.Q != nil {
+		if c.G != nil {
+			c.gt.Matrix = c.G
+			c.gq.Mul(c.G, c.Q)
+			c.gqgt.Mul(&c.gq, &c.gt)
+			c.mpmt.Add(&c.mpmt, &c.gqgt)
+		} else {
+			c.mpmt.Add(&c.mpmt, c.Q)
+		}
+	}
+
+	symmetrize(dst, &c.mpmt)
+}
+
+// symmetrize stores (m + m^T) / 2 in dst; an empty dst is resized and a
+// non-empty dst of another dimension panics with mat.ErrShape
+func symmetrize(dst *mat.SymDense, m *mat.Dense) {
+	n, _ := m.Dims()
+	if !dst.IsEmpty() && dst.Symmetric() != n {
+		panic(mat.ErrShape)
+	}
+	if dst.IsEmpty() {
+		dst.ReuseAsSym(n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			dst.SetSym(i, j, 0.5*(m.At(i, j)+m.At(j, i)))
+		}
+	}
+}
@@ -56,3 +56,135 @@ func TestCovariancePredict(t *testing.T) {
 	}
 
 }
+
+func TestCovariancePredictSym(t *testing.T) {
+
+	md := mat.NewDense(3, 3, []float64{
+		0, 1, 0,
+		0, 0, 1,
+		1, 0, 0,
+	})
+	systemNoise := NewCovariance(md)
+
+	p := mat.NewSymDense(3, []float64{
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+	})
+	pNext := systemNoise.PredictSym(p)
+
+	expected1 := mat.NewDense(3, 3, []float64{
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+	})
+	if !mat.EqualApprox(pNext, expected1, 1e-8) {
+		fmt.Println("received:", pNext)
+		fmt.Println("expected:", expected1)
+		t.Error("predict failed without noise")
+	}
+
+	// test with extra noise
+	noise := mat.NewSymDense(3, []float64{
+		0.1, 0.1, 0.3,
+		0.1, 0.2, 0.1,
+		0.3, 0.1, 0.1,
+	})
+	systemNoise.Q = noise
+
+	pNext = systemNoise.PredictSym(p)
+
+	expected2 := mat.NewDense(3, 3, []float64{
+		1.1, 0.1, 0.3,
+		0.1, 1.2, 0.1,
+		0.3, 0.1, 1.1,
+	})
+	if !mat.EqualApprox(pNext, expected2, 1e-8) {
+		fmt.Println("received:", pNext)
+		fmt.Println("expected:", expected2)
+		t.Error("predict failed with noise")
+	}
+
+}
+
+func TestCovariancePredictWithNoiseInput(t *testing.T) {
+
+	md := mat.NewDense(2, 2, []float64{
+		1, 0.1,
+		0, 1,
+	})
+	g := mat.NewDense(2, 1, []float64{
+		0.005,
+		0.1,
+	})
+	q := mat.NewSymDense(1, []float64{4})
+
+	cov, err := NewCovarianceWithNoise(md, g, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := mat.NewSymDense(2, []float64{
+		1, 0,
+		0, 2,
+	})
+	var pNext mat.SymDense
+	cov.PredictTo(&pNext, p)
+
+	// Md P Md^T = [1.02 0.2; 0.2 2], G Q G^T = [1e-4 2e-3; 2e-3 0.04]
+	expected := mat.NewSymDense(2, []float64{
+		1.0201, 0.202,
+		0.202, 2.04,
+	})
+	if !mat.EqualApprox(&pNext, expected, 1e-8) {
+		fmt.Println("received:", mat.Formatted(&pNext))
+		fmt.Println("expected:", mat.Formatted(expected))
+		t.Error("predict failed with noise input matrix")
+	}
+
+	// an empty dst is resized, a dst of a different size panics
+	var empty mat.SymDense
+	cov.PredictTo(&empty, p)
+	if !mat.EqualApprox(&empty, expected, 1e-8) {
+		t.Error("predict failed with empty destination")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for wrong destination size")
+			}
+		}()
+		cov.PredictTo(mat.NewSymDense(3, nil), p)
+	}()
+
+	// dimension checks
+	if _, err := NewCovarianceWithNoise(md, mat.NewDense(3, 1, nil), q); err == nil {
+		t.Error("expected error for wrong G dimensions")
+	}
+	if _, err := NewCovarianceWithNoise(md, nil, q); err == nil {
+		t.Error("expected error for wrong Q dimensions without G")
+	}
+}
+
+func TestCovariancePredictAllocs(t *testing.T) {
+
+	md := mat.NewDense(2, 2, []float64{
+		1, 0.1,
+		0, 1,
+	})
+	g := mat.NewDense(2, 1, []float64{0.005, 0.1})
+	cov, err := NewCovarianceWithNoise(md, g, mat.NewSymDense(1, []float64{1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := mat.NewSymDense(2, []float64{1, 0, 0, 1})
+	cov.PredictTo(p, p)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		cov.PredictTo(p, p)
+	})
+	if allocs != 0 {
+		t.Errorf("PredictTo allocated %v times per run", allocs)
+	}
+}
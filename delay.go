@@ -0,0 +1,414 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//ErrDelays is returned when input or output delays cannot be represented
+//exactly by the result of an operation, e.g. delays inside a feedback loop.
+//The delays can be approximated by states with Pade before the operation.
+var ErrDelays = errors.New("delays are not supported by the operation, see Pade")
+
+//Pade returns the Pade approximation of the given order of the time delay
+//exp(-s tau) as a SISO system with order states.
+func Pade(tau float64, order int) (*System, error) {
+	if order < 1 {
+		return nil, errors.New("Pade: order should be at least 1")
+	}
+	if tau <= 0 || math.IsInf(tau, 0) || math.IsNaN(tau) {
+		return nil, errors.New("Pade: delay should be positive")
+	}
+
+	// den_k = c_k tau^k, num_k = (-1)^k c_k tau^k with
+	// c_k = (2N - k)! N! / ((2N)! k! (N - k)!)
+	n := order
+	den := make([]float64, n+1)
+	num := make([]float64, n+1)
+	ck, pow := 1.0, 1.0
+	for k := 0; k <= n; k++ {
+		if k > 0 {
+			ck *= float64(n-k+1) / float64(k*(2*n-k+1))
+			pow *= tau
+		}
+		den[k] = ck * pow
+		num[k] = ck * pow
+		if k%2 == 1 {
+			num[k] = -num[k]
+		}
+	}
+
+	// controllable canonical form of num / den with monic denominator
+	a := mat.NewDense(n, n, nil)
+	b := mat.NewDense(n, 1, nil)
+	c := mat.NewDense(1, n, nil)
+	feedthrough := num[n] / den[n]
+	for k := 0; k < n; k++ {
+		if k < n-1 {
+			a.Set(k, k+1, 1)
+		}
+		a.Set(n-1, k, -den[k]/den[n])
+		c.Set(0, k, num[k]/den[n]-feedthrough*den[k]/den[n])
+	}
+	b.Set(n-1, 0, 1)
+	return NewSystem(a, b, c, mat.NewDense(1, 1, []float64{feedthrough}))
+}
+
+//Pade returns the system with the input and output delays replaced by
+//Pade approximations of the given order. The approximations of the input
+//delays are in series before and those of the output delays after the system.
+//The states of the system come first, followed by the states of the approximations.
+func (s *System) Pade(order int) (*System, error) {
+	if err := s.checkDelays(); err != nil {
+		return nil, err
+	}
+	r := s.realization()
+	_, m, p := r.dims()
+
+	// blocks for the delayed inputs and outputs
+	rs := []realization{r}
+	var inBlock, outBlock []int
+	for j := 0; j < m; j++ {
+		inBlock = append(inBlock, -1)
+		if tau := delayAt(s.InputDelay, j); tau > 0 {
+			pade, err := Pade(tau, order)
+			if err != nil {
+				return nil, err
+			}
+			inBlock[j] = len(rs) - 1
+			rs = append(rs, pade.realization())
+		}
+	}
+	for i := 0; i < p; i++ {
+		outBlock = append(outBlock, -1)
+		if tau := delayAt(s.OutputDelay, i); tau > 0 {
+			pade, err := Pade(tau, order)
+			if err != nil {
+				return nil, err
+			}
+			outBlock[i] = len(rs) - 1
+			rs = append(rs, pade.realization())
+		}
+	}
+	total := appendRealizations(rs...)
+	_, mu, py := total.dims()
+
+	// u = [u_sys; u_blocks], y = [y_sys; y_blocks]: every block is SISO,
+	// so the input and output of block k are at m + k and p + k
+	mm := mat.NewDense(mu, py, nil)
+	n := mat.NewDense(mu, m, nil)
+	sm := mat.NewDense(p, py, nil)
+	for j, k := range inBlock {
+		if k < 0 {
+			n.Set(j, j, 1)
+			continue
+		}
+		n.Set(m+k, j, 1)
+		mm.Set(j, p+k, 1)
+	}
+	for i, k := range outBlock {
+		if k < 0 {
+			sm.Set(i, i, 1)
+			continue
+		}
+		mm.Set(m+k, i, 1)
+		sm.Set(i, p+k, 1)
+	}
+
+	res, err := total.interconnect(mm, n, sm, nil)
+	if err != nil {
+		return nil, err
+	}
+	res.inputs = copySignals(r.inputs)
+	res.outputs = copySignals(r.outputs)
+	sys, err := res.system()
+	if err != nil {
+		return nil, err
+	}
+	sys.X0, sys.U0, sys.Y0, sys.Dx0 = s.X0, s.U0, s.Y0, s.Dx0
+	if s.X0 != nil || s.Dx0 != nil {
+		// the states of the approximations are deviations with zero offset
+		nx, _ := sys.A.Dims()
+		sys.X0, sys.Dx0 = extendVec(s.X0, nx), extendVec(s.Dx0, nx)
+	}
+	return sys, nil
+}
+
+// hasDelays returns true if any input or output is delayed
+func (s *System) hasDelays() bool {
+	for _, tau := range s.InputDelay {
+		if tau != 0 {
+			return true
+		}
+	}
+	for _, tau := range s.OutputDelay {
+		if tau != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDelays checks the number and values of the delays
+func (s *System) checkDelays() error {
+	p, m := s.D.Dims()
+	if s.InputDelay != nil && len(s.InputDelay) != m {
+		return errors.New("InputDelay should have a delay for every input")
+	}
+	if s.OutputDelay != nil && len(s.OutputDelay) != p {
+		return errors.New("OutputDelay should have a delay for every output")
+	}
+	for _, delays := range [][]float64{s.InputDelay, s.OutputDelay} {
+		for _, tau := range delays {
+			if tau < 0 || math.IsInf(tau, 0) || math.IsNaN(tau) {
+				return errors.New("delays should be finite and non-negative")
+			}
+		}
+	}
+	return nil
+}
+
+// discretizeDelays discretizes the system with a zero-order hold and
+// the delays tau = d * dt + phi, 0 <= phi < dt.
+//
+// An input delayed by tau acts with u(k-d) during the last dt - phi and with
+// u(k-d-1) during the first phi of every sample interval, i.e.
+//  x(k+1) = A_d x(k) + G0 u(k-d) + G1 u(k-d-1) with
+//  G0 = Int_0^(dt-phi) exp(A t) dt * B, G1 = exp(A (dt-phi)) Int_0^phi exp(A t) dt * B
+// The past inputs are stored in shift registers, which are appended to the states.
+// Delayed outputs y(k) = w(k-d-1) are stored in shift registers of the output
+// w(k) at the time (k+1) dt - phi between the samples. Fractional output delays
+// are only supported for systems without input delays.
+func (s *System) discretizeDelays(dt float64) (*Discrete, error) {
+	if err := s.checkDelays(); err != nil {
+		return nil, err
+	}
+	n, m := s.B.Dims()
+	p, _ := s.C.Dims()
+
+	ad, err := discretize(s.A, dt)
+	if err != nil {
+		return nil, errors.New("discretization of A failed")
+	}
+
+	inSteps, inFrac := make([]int, m), make([]float64, m)
+	inBuf, outBuf := make([]int, m), make([]int, p)
+	total := n
+	inputDelayed := false
+	for j := 0; j < m; j++ {
+		inSteps[j], inFrac[j] = splitDelay(delayAt(s.InputDelay, j), dt)
+		inBuf[j] = inSteps[j]
+		if inFrac[j] > 0 {
+			inBuf[j]++
+		}
+		if inBuf[j] > 0 {
+			inputDelayed = true
+		}
+		total += inBuf[j]
+	}
+	outSteps, outFrac := make([]int, p), make([]float64, p)
+	for i := 0; i < p; i++ {
+		outSteps[i], outFrac[i] = splitDelay(delayAt(s.OutputDelay, i), dt)
+		outBuf[i] = outSteps[i]
+		if outFrac[i] > 0 {
+			if inputDelayed {
+				return nil, errors.New("fractional output delays are not supported with input delays")
+			}
+			outBuf[i]++
+		}
+		total += outBuf[i]
+	}
+
+	a := mat.NewDense(total, total, nil)
+	b := mat.NewDense(total, m, nil)
+	c := mat.NewDense(p, total, nil)
+	d := mat.NewDense(p, m, nil)
+	setBlock(a, 0, 0, ad)
+	setBlock(c, 0, 0, s.C)
+
+	// input shift registers z_j[l] = u_j(k-1-l)
+	offset := n
+	inStart := make([]int, m)
+	for j := 0; j < m; j++ {
+		inStart[j] = offset
+		bj := mat.NewDense(n, 1, mat.Col(nil, j, s.B))
+		dj := mat.Col(nil, j, s.D)
+
+		// G0 from u_j(k-d)
+		g0, err := integrate(s.A, bj, dt-inFrac[j])
+		if err != nil {
+			return nil, errors.New("discretization of B failed")
+		}
+		if inSteps[j] == 0 {
+			setBlock(b, 0, j, g0)
+		} else {
+			setBlock(a, 0, offset+inSteps[j]-1, g0)
+		}
+
+		// G1 from u_j(k-d-1)
+		if inFrac[j] > 0 {
+			g1, err := integrate(s.A, bj, inFrac[j])
+			if err != nil {
+				return nil, errors.New("discretization of B failed")
+			}
+			phi, err := discretize(s.A, dt-inFrac[j])
+			if err != nil {
+				return nil, errors.New("discretization of B failed")
+			}
+			var pg mat.Dense
+			pg.Mul(phi, g1)
+			setBlock(a, 0, offset+inSteps[j], &pg)
+		}
+
+		// the output sees the held input u_j(k - inBuf)
+		for i := 0; i < p; i++ {
+			if inBuf[j] == 0 {
+				d.Set(i, j, dj[i])
+			} else {
+				c.Set(i, offset+inBuf[j]-1, dj[i])
+			}
+		}
+
+		// shift register
+		if inBuf[j] > 0 {
+			b.Set(offset, j, 1)
+			for l := 1; l < inBuf[j]; l++ {
+				a.Set(offset+l, offset+l-1, 1)
+			}
+		}
+		offset += inBuf[j]
+	}
+
+	// output shift registers q_i[l] = w_i(k-1-l)
+	var cu mat.Dense
+	cu.CloneFrom(c)
+	du := mat.DenseCopyOf(d)
+	outStart := make([]int, p)
+	for i := 0; i < p; i++ {
+		outStart[i] = offset
+		if outBuf[i] == 0 {
+			continue
+		}
+
+		// w_i = C_i x + D_i u, or between the samples
+		// w_i = C_i exp(A (dt-phi)) x + (C_i Int_0^(dt-phi) exp(A t) dt B + D_i) u
+		cw := mat.Row(nil, i, &cu)
+		dw := mat.Row(nil, i, du)
+		if outFrac[i] > 0 {
+			ci := mat.NewDense(1, n, mat.Row(nil, i, s.C))
+			phi, err := discretize(s.A, dt-outFrac[i])
+			if err != nil {
+				return nil, errors.New("discretization of C failed")
+			}
+			gam, err := integrate(s.A, s.B, dt-outFrac[i])
+			if err != nil {
+				return nil, errors.New("discretization of C failed")
+			}
+			var cp, cg mat.Dense
+			cp.Mul(ci, phi)
+			cg.Mul(ci, gam)
+			cw = make([]float64, total)
+			copy(cw, cp.RawRowView(0))
+			for j := range dw {
+				dw[j] += cg.At(0, j)
+			}
+		}
+		a.SetRow(offset, cw)
+		b.SetRow(offset, dw)
+		for l := 1; l < outBuf[i]; l++ {
+			a.Set(offset+l, offset+l-1, 1)
+		}
+
+		// y_i = q_i[last]
+		for k := 0; k < total; k++ {
+			c.Set(i, k, 0)
+		}
+		for j := 0; j < m; j++ {
+			d.Set(i, j, 0)
+		}
+		c.Set(i, offset+outBuf[i]-1, 1)
+		offset += outBuf[i]
+	}
+
+	states := [][]Signal{s.States}
+	sizes := []int{n}
+	for j := 0; j < m; j++ {
+		states, sizes = append(states, nil), append(sizes, inBuf[j])
+	}
+	for i := 0; i < p; i++ {
+		states, sizes = append(states, nil), append(sizes, outBuf[i])
+	}
+	disc := &Discrete{
+		Ad:      a,
+		Bd:      b,
+		C:       c,
+		D:       d,
+		Dt:      dt,
+		Inputs:  copySignals(s.Inputs),
+		Outputs: copySignals(s.Outputs),
+		States:  concatSignals(states, sizes),
+	}
+
+	// the shift registers hold absolute inputs and outputs, so that
+	// their operating point is u0 and y0
+	if err := disc.discretizeOperatingPoint(s, dt); err != nil {
+		return nil, err
+	}
+	if disc.X0 != nil || disc.U0 != nil || disc.Y0 != nil {
+		x0 := extendVec(disc.X0, total)
+		for j := 0; j < m; j++ {
+			for l := 0; l < inBuf[j]; l++ {
+				x0.SetVec(inStart[j]+l, vecAt(disc.U0, j))
+			}
+		}
+		for i := 0; i < p; i++ {
+			for l := 0; l < outBuf[i]; l++ {
+				x0.SetVec(outStart[i]+l, vecAt(disc.Y0, i))
+			}
+		}
+		disc.X0 = x0
+	}
+	if disc.Dx0 != nil {
+		disc.Dx0 = extendVec(disc.Dx0, total)
+	}
+	return disc, nil
+}
+
+// splitDelay splits the delay tau into d * dt + phi with 0 <= phi < dt
+func splitDelay(tau, dt float64) (int, float64) {
+	steps := math.Floor(tau/dt + 1e-9)
+	phi := tau - steps*dt
+	if phi < 1e-9*dt {
+		phi = 0
+	}
+	return int(steps), phi
+}
+
+// delayAt returns the delay i or zero
+func delayAt(delays []float64, i int) float64 {
+	if i < len(delays) {
+		return delays[i]
+	}
+	return 0
+}
+
+// vecAt returns the element i of v or zero if v is nil
+func vecAt(v *mat.VecDense, i int) float64 {
+	if v == nil {
+		return 0
+	}
+	return v.AtVec(i)
+}
+
+// extendVec returns a vector of length n with the leading elements of v
+func extendVec(v *mat.VecDense, n int) *mat.VecDense {
+	res := mat.NewVecDense(n, nil)
+	if v != nil {
+		for i := 0; i < v.Len(); i++ {
+			res.SetVec(i, v.AtVec(i))
+		}
+	}
+	return res
+}
@@ -0,0 +1,170 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPade(t *testing.T) {
+	tau := 0.5
+	pade, err := Pade(tau, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []float64{0, 0.5, 1, 2} {
+		h := sisoAt(t, pade, w)
+		if math.Abs(cmplx.Abs(h)-1) > 1e-12 {
+			t.Error("Pade approximation should be all-pass, received:", cmplx.Abs(h))
+		}
+		if e := cmplx.Exp(complex(0, -w*tau)); cmplx.Abs(h-e) > 1e-5 {
+			fmt.Println("received:", h, "expected:", e)
+			t.Error("wrong Pade approximation at", w)
+		}
+	}
+
+	// delays in the frequency response and Pade approximation of the system
+	sys := newTestFirstOrder(2, 1, 0)
+	sys.InputDelay = []float64{0.2}
+	sys.OutputDelay = []float64{0.1}
+	approx, err := sys.Pade(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := approx.A.Dims(); n != 9 {
+		t.Error("Pade should add 4 states per delay, received:", n)
+	}
+	w := 1.5
+	expected := 2 / complex(1, w) * cmplx.Exp(complex(0, -w*0.3))
+	if h := sisoAt(t, sys, w); cmplx.Abs(h-expected) > 1e-12 {
+		fmt.Println("received:", h, "expected:", expected)
+		t.Error("wrong frequency response with delays")
+	}
+	if h := sisoAt(t, approx, w); cmplx.Abs(h-expected) > 1e-6 {
+		fmt.Println("received:", h, "expected:", expected)
+		t.Error("wrong frequency response of Pade approximation")
+	}
+
+	if _, err := Pade(0, 2); err == nil {
+		t.Error("expected error for zero delay")
+	}
+}
+
+func TestDiscretizeDelays(t *testing.T) {
+	dt := 0.1
+	var config = []struct {
+		Name        string
+		InputDelay  []float64
+		OutputDelay []float64
+		States      int
+	}{
+		{Name: "integer input", InputDelay: []float64{0.2}, States: 3},
+		{Name: "fractional input", InputDelay: []float64{0.25}, States: 4},
+		{Name: "integer output", OutputDelay: []float64{0.3}, States: 4},
+		{Name: "fractional output", OutputDelay: []float64{0.25}, States: 4},
+	}
+
+	for _, cfg := range config {
+		// x' = -x + u, y = x + 0.5 u for a unit step at t = 0
+		sys := newTestFirstOrder(1, 1, 0.5)
+		sys.InputDelay = cfg.InputDelay
+		sys.OutputDelay = cfg.OutputDelay
+		tau := delayAt(cfg.InputDelay, 0) + delayAt(cfg.OutputDelay, 0)
+
+		disc, err := sys.Discretize(dt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _ := disc.Ad.Dims()
+		if n != cfg.States {
+			t.Error(cfg.Name, ": wrong number of states:", n)
+		}
+
+		x := mat.NewVecDense(n, nil)
+		u := mat.NewVecDense(1, []float64{1})
+		for k := 0; k <= 10; k++ {
+			tk := float64(k) * dt
+			expected := 0.0
+			if tk >= tau-1e-12 {
+				expected = 1.5 - math.Exp(-(tk - tau))
+			}
+			if y := disc.Response(x, u).AtVec(0); math.Abs(y-expected) > 1e-12 {
+				t.Error(cfg.Name, ": wrong output at", tk, "received:", y, "expected:", expected)
+			}
+			x = mat.VecDenseCopyOf(disc.Predict(x, u))
+		}
+	}
+
+	sys := newTestFirstOrder(1, 1, 0)
+	sys.InputDelay = []float64{0.1}
+	sys.OutputDelay = []float64{0.05}
+	if _, err := sys.Discretize(dt); err == nil {
+		t.Error("expected error for fractional output delay with input delay")
+	}
+}
+
+func TestDelayInterconnection(t *testing.T) {
+	g1 := newTestFirstOrder(2, 1, 0)
+	g1.InputDelay = []float64{0.3}
+	g2 := newTestFirstOrder(1, 3, 0.5)
+	g2.OutputDelay = []float64{0.2}
+
+	series, err := g1.Series(g2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []float64{0.1, 1, 10} {
+		expected := sisoAt(t, g1, w) * sisoAt(t, g2, w)
+		if cmplx.Abs(sisoAt(t, series, w)-expected) > 1e-12 {
+			t.Error("series of delayed systems wrong at", w, ":", sisoAt(t, series, w), expected)
+		}
+	}
+
+	// delays are kept by the channel selection, the minimal realization and Append
+	appended, err := g1.Append(g2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floats.Equal(appended.InputDelay, []float64{0.3, 0}) || !floats.Equal(appended.OutputDelay, []float64{0, 0.2}) {
+		t.Error("Append should stack the delays, received:", appended.InputDelay, appended.OutputDelay)
+	}
+	sub, err := appended.Subsystem([]int{1}, []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	minimal, err := sub.Minimal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minimal.InputDelay != nil || !floats.Equal(minimal.OutputDelay, []float64{0.2}) {
+		t.Error("Subsystem and Minimal should keep the delays, received:", minimal.InputDelay, minimal.OutputDelay)
+	}
+
+	// delays between the connected systems or inside loops are not supported
+	if _, err := g2.Series(g1); err != ErrDelays {
+		t.Error("expected ErrDelays for delayed connection, received:", err)
+	}
+	if _, err := g1.Parallel(g2); err != ErrDelays {
+		t.Error("expected ErrDelays for different delays, received:", err)
+	}
+	if _, err := g1.Feedback(g2, -1, nil, nil); err != ErrDelays {
+		t.Error("expected ErrDelays for delayed loop, received:", err)
+	}
+	if _, err := LFT(appended, g1, 1, 1); err != ErrDelays {
+		t.Error("expected ErrDelays for delayed LFT, received:", err)
+	}
+	if _, _, err := g1.AugmentIntegrators([]int{0}); err != ErrDelays {
+		t.Error("expected ErrDelays for delayed integrators, received:", err)
+	}
+	pade, err := g1.Pade(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pade.Feedback(newTestFirstOrder(1, 3, 0.5), -1, nil, nil); err != nil {
+		t.Error("feedback with the Pade approximation failed:", err)
+	}
+}
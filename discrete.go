@@ -11,13 +11,28 @@ import (
 // The parameters are:
 // 	A_d: Discretized Ssystem matrix
 // 	B_d: Discretized Control matrix
+// 	Dt:  Sample time
 //
+// Inputs, outputs and states can optionally be described by
+// the signals Inputs, Outputs and States.
+//
+// For linearizations, x, u and y are deviations from the operating
+// point X0, U0, Y0 with the state change Dx0 per sample,
+// see SetOperatingPoint.
 //
 type Discrete struct {
 	Ad          *mat.Dense
 	Bd          *mat.Dense
 	C           *mat.Dense
 	D           *mat.Dense
+	Dt          float64
+	Inputs      []Signal
+	Outputs     []Signal
+	States      []Signal
+	X0          *mat.VecDense
+	U0          *mat.VecDense
+	Y0          *mat.VecDense
+	Dx0         *mat.VecDense
 	ax, bu, sum mat.VecDense // Workspace for multAndSumOp
 }
 
@@ -41,6 +56,7 @@ func NewDiscrete(A, B, C, D *mat.Dense, dt float64) (*Discrete, error) {
 		Bd: bd,
 		C:  C,
 		D:  D,
+		Dt: dt,
 	}, nil
 }
 
@@ -70,3 +86,8 @@ func (d *Discrete) Observable() (bool, error) {
 	// rank( S=[C, C A, C A^2, ..., C A^n-1]' ) = n
 	return checkObservability(d.Ad, d.C)
 }
+
+// Poles returns the poles of the LTI system, i.e. the eigenvalues of A_d.
+func (d *Discrete) Poles() ([]complex128, error) {
+	return eigenvalues(d.Ad)
+}
@@ -0,0 +1,450 @@
+package lti
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"gonum.org/v1/gonum/lapack/gonum"
+	"gonum.org/v1/gonum/mat"
+)
+
+//FrequencyResponse evaluates the transfer function matrix
+//G(jw) = C * (jw I - A)^-1 * B + D at the angular frequencies omegas (rad/s).
+//Input and output delays are included by the factors exp(-jw tau).
+func (s *System) FrequencyResponse(omegas []float64) ([]*mat.CDense, error) {
+	e, err := s.evaluator()
+	if err != nil {
+		return nil, err
+	}
+	return e.response(omegas)
+}
+
+//FrequencyGrid returns n logarithmically spaced angular frequencies (rad/s)
+//that cover two decades below and above the poles and zeros of the system.
+func (s *System) FrequencyGrid(n int) ([]float64, error) {
+	return frequencyGrid(s.A, s.B, s.C, s.D, 0, n)
+}
+
+//FrequencyResponse evaluates the transfer function matrix
+//G(z) = C * (z I - A_d)^-1 * B_d + D at z = exp(jw Dt) for the
+//angular frequencies omegas (rad/s).
+func (d *Discrete) FrequencyResponse(omegas []float64) ([]*mat.CDense, error) {
+	e, err := d.evaluator()
+	if err != nil {
+		return nil, err
+	}
+	return e.response(omegas)
+}
+
+//FrequencyGrid returns n logarithmically spaced angular frequencies (rad/s)
+//that cover two decades below the poles and zeros of the system
+//up to the Nyquist frequency pi/Dt.
+func (d *Discrete) FrequencyGrid(n int) ([]float64, error) {
+	return frequencyGrid(d.Ad, d.Bd, d.C, d.D, d.Dt, n)
+}
+
+// evaluatorOf returns the frequency response evaluator of a System or Discrete
+func evaluatorOf(r FrequencyResponder) (*freqEvaluator, error) {
+	switch sys := r.(type) {
+	case *System:
+		return sys.evaluator()
+	case *Discrete:
+		return sys.evaluator()
+	}
+	return nil, errors.New("frequency analysis needs a System or Discrete")
+}
+
+// evaluator returns the frequency response evaluator of the system
+func (s *System) evaluator() (*freqEvaluator, error) {
+	if err := s.checkDelays(); err != nil {
+		return nil, err
+	}
+	e, err := newFreqEvaluator(s.A, s.B, s.C, s.D, 0)
+	if err != nil {
+		return nil, err
+	}
+	e.inputDelay, e.outputDelay = s.InputDelay, s.OutputDelay
+	return e, nil
+}
+
+// evaluator returns the frequency response evaluator of the system
+func (d *Discrete) evaluator() (*freqEvaluator, error) {
+	if d.Dt <= 0 {
+		return nil, errors.New("sample time Dt should be positive")
+	}
+	return newFreqEvaluator(d.Ad, d.Bd, d.C, d.D, d.Dt)
+}
+
+//Magnitude returns the magnitude |G_ij| of the frequency response
+//from input j to output i.
+func Magnitude(h []*mat.CDense, i, j int) []float64 {
+	mag := make([]float64, len(h))
+	for k, g := range h {
+		mag[k] = cmplx.Abs(g.At(i, j))
+	}
+	return mag
+}
+
+//MagnitudeDB returns the magnitude 20 log10 |G_ij| in decibel
+//of the frequency response from input j to output i.
+func MagnitudeDB(h []*mat.CDense, i, j int) []float64 {
+	mag := Magnitude(h, i, j)
+	for k := range mag {
+		mag[k] = 20 * math.Log10(mag[k])
+	}
+	return mag
+}
+
+//Phase returns the unwrapped phase in radians of the frequency response
+//from input j to output i.
+func Phase(h []*mat.CDense, i, j int) []float64 {
+	phase := make([]float64, len(h))
+	for k, g := range h {
+		phase[k] = cmplx.Phase(g.At(i, j))
+	}
+	return UnwrapPhase(phase)
+}
+
+//UnwrapPhase removes the jumps of 2 pi from a sequence of phase angles in radians.
+//The input slice is modified in place and returned.
+func UnwrapPhase(phase []float64) []float64 {
+	offset := 0.0
+	for k := 1; k < len(phase); k++ {
+		prev := phase[k-1]
+		cur := phase[k] + offset
+		for cur-prev > math.Pi {
+			cur -= 2 * math.Pi
+			offset -= 2 * math.Pi
+		}
+		for cur-prev < -math.Pi {
+			cur += 2 * math.Pi
+			offset += 2 * math.Pi
+		}
+		phase[k] = cur
+	}
+	return phase
+}
+
+//LogSpace returns n logarithmically spaced values between lo and hi
+func LogSpace(lo, hi float64, n int) []float64 {
+	if n <= 0 {
+		return nil
+	}
+	if n == 1 {
+		return []float64{lo}
+	}
+	a, b := math.Log10(lo), math.Log10(hi)
+	values := make([]float64, n)
+	for k := range values {
+		values[k] = math.Pow(10, a+(b-a)*float64(k)/float64(n-1))
+	}
+	return values
+}
+
+// freqEvaluator evaluates a transfer function matrix after an orthogonal
+// reduction of A to upper Hessenberg form H = Q^T A Q, so that every
+// evaluation only needs O(n^2) operations per input
+type freqEvaluator struct {
+	n, m, p int
+	h       []float64 // Hessenberg matrix H (n x n, row major)
+	b       []float64 // Q^T B (n x m, row major)
+	c       *mat.Dense
+	d       *mat.Dense
+	dt      float64 // Sample time, 0 for time-continuous systems
+
+	inputDelay, outputDelay []float64 // Optional delays of time-continuous systems
+
+	lu  []complex128 // Workspace for solve
+	rhs []complex128
+}
+
+// newFreqEvaluator reduces the system to Hessenberg form
+func newFreqEvaluator(a, b, c, d *mat.Dense, dt float64) (*freqEvaluator, error) {
+	n, _ := a.Dims()
+	_, m := b.Dims()
+	p, _ := c.Dims()
+	if dr, dc := d.Dims(); dr != p || dc != m {
+		return nil, errors.New("D dimensions do not match B and C")
+	}
+
+	e := &freqEvaluator{
+		n: n, m: m, p: p,
+		c:   c,
+		d:   d,
+		dt:  dt,
+		lu:  make([]complex128, n*n),
+		rhs: make([]complex128, n*m),
+	}
+	if n == 0 {
+		return e, nil
+	}
+
+	// H = Q^T A Q
+	var impl gonum.Implementation
+	h := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			h[i*n+j] = a.At(i, j)
+		}
+	}
+	tau := make([]float64, n)
+	work := make([]float64, 1)
+	impl.Dgehrd(n, 0, n-1, h, n, tau[:n-1], work, -1)
+	work = make([]float64, int(work[0])+1)
+	impl.Dgehrd(n, 0, n-1, h, n, tau[:n-1], work, len(work))
+
+	q := make([]float64, n*n)
+	copy(q, h)
+	impl.Dorghr(n, 0, n-1, q, n, tau[:n-1], work, -1)
+	if int(work[0]) > len(work) {
+		work = make([]float64, int(work[0]))
+	}
+	impl.Dorghr(n, 0, n-1, q, n, tau[:n-1], work, len(work))
+
+	// zero the reflectors below the subdiagonal
+	for i := 2; i < n; i++ {
+		for j := 0; j < i-1; j++ {
+			h[i*n+j] = 0
+		}
+	}
+	e.h = h
+
+	qm := mat.NewDense(n, n, q)
+	var qtb, cq mat.Dense
+	qtb.Mul(qm.T(), b)
+	cq.Mul(c, qm)
+	e.b = qtb.RawMatrix().Data
+	if stride := qtb.RawMatrix().Stride; stride != m {
+		e.b = make([]float64, n*m)
+		for i := 0; i < n; i++ {
+			copy(e.b[i*m:(i+1)*m], qtb.RawRowView(i))
+		}
+	}
+	e.c = &cq
+
+	return e, nil
+}
+
+// point returns the complex frequency s = jw or z = exp(jw Dt)
+func (e *freqEvaluator) point(omega float64) complex128 {
+	if e.dt > 0 {
+		return cmplx.Exp(complex(0, omega*e.dt))
+	}
+	return complex(0, omega)
+}
+
+// response evaluates the frequency response at the angular frequencies omegas
+func (e *freqEvaluator) response(omegas []float64) ([]*mat.CDense, error) {
+	res := make([]*mat.CDense, len(omegas))
+	for k, w := range omegas {
+		g := mat.NewCDense(e.p, e.m, nil)
+		if err := e.evalTo(g, e.point(w)); err != nil {
+			return nil, err
+		}
+		res[k] = g
+	}
+	return res, nil
+}
+
+// evalTo stores G(s) = C (sI - A)^-1 B + D in dst
+func (e *freqEvaluator) evalTo(dst *mat.CDense, s complex128) error {
+	n, m := e.n, e.m
+
+	if n > 0 {
+		if err := e.solve(s); err != nil {
+			return err
+		}
+	}
+
+	// G = C X + D with the delays exp(-s tau)
+	for i := 0; i < e.p; i++ {
+		for j := 0; j < m; j++ {
+			sum := complex(e.d.At(i, j), 0)
+			for k := 0; k < n; k++ {
+				sum += complex(e.c.At(i, k), 0) * e.rhs[k*m+j]
+			}
+			if tau := delayAt(e.inputDelay, j) + delayAt(e.outputDelay, i); tau > 0 {
+				sum *= cmplx.Exp(-s * complex(tau, 0))
+			}
+			dst.Set(i, j, sum)
+		}
+	}
+	return nil
+}
+
+// at returns the scalar transfer function G_ij(s)
+func (e *freqEvaluator) at(s complex128, i, j int) (complex128, error) {
+	g := mat.NewCDense(e.p, e.m, nil)
+	if err := e.evalTo(g, s); err != nil {
+		return 0, err
+	}
+	return g.At(i, j), nil
+}
+
+// solve solves (sI - H) X = Q^T B by Gaussian elimination with partial
+// pivoting, which keeps the Hessenberg structure, and stores X in e.rhs
+func (e *freqEvaluator) solve(s complex128) error {
+	n, m := e.n, e.m
+	lu, x := e.lu, e.rhs
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			lu[i*n+j] = complex(-e.h[i*n+j], 0)
+		}
+		lu[i*n+i] += s
+	}
+	for i := range x {
+		x[i] = complex(e.b[i], 0)
+	}
+
+	// forward elimination of the subdiagonal
+	for k := 0; k < n-1; k++ {
+		if cmplx.Abs(lu[(k+1)*n+k]) > cmplx.Abs(lu[k*n+k]) {
+			for j := k; j < n; j++ {
+				lu[k*n+j], lu[(k+1)*n+j] = lu[(k+1)*n+j], lu[k*n+j]
+			}
+			for j := 0; j < m; j++ {
+				x[k*m+j], x[(k+1)*m+j] = x[(k+1)*m+j], x[k*m+j]
+			}
+		}
+		if lu[k*n+k] == 0 {
+			return errors.New("frequency coincides with a pole")
+		}
+		l := lu[(k+1)*n+k] / lu[k*n+k]
+		if l == 0 {
+			continue
+		}
+		for j := k; j < n; j++ {
+			lu[(k+1)*n+j] -= l * lu[k*n+j]
+		}
+		for j := 0; j < m; j++ {
+			x[(k+1)*m+j] -= l * x[k*m+j]
+		}
+	}
+
+	// back substitution
+	for k := n - 1; k >= 0; k-- {
+		piv := lu[k*n+k]
+		if piv == 0 {
+			return errors.New("frequency coincides with a pole")
+		}
+		for j := 0; j < m; j++ {
+			sum := x[k*m+j]
+			for i := k + 1; i < n; i++ {
+				sum -= lu[k*n+i] * x[i*m+j]
+			}
+			x[k*m+j] = sum / piv
+		}
+	}
+	return nil
+}
+
+// frequencyGrid returns a logarithmic frequency grid based on the
+// poles and zeros of the system; dt is 0 for time-continuous systems
+func frequencyGrid(a, b, c, d *mat.Dense, dt float64, n int) ([]float64, error) {
+	if n <= 0 {
+		return nil, errors.New("number of frequencies should be positive")
+	}
+
+	features, err := eigenvalues(a)
+	if err != nil {
+		return nil, err
+	}
+	features = append(features, transmissionZeros(a, b, c, d)...)
+
+	// natural frequencies of poles and zeros
+	var freqs []float64
+	for _, v := range features {
+		if dt > 0 {
+			if v == 0 {
+				continue
+			}
+			v = cmplx.Log(v) / complex(dt, 0)
+		}
+		if w := cmplx.Abs(v); w > 1e-12 && !math.IsInf(w, 0) {
+			freqs = append(freqs, w)
+		}
+	}
+	sort.Float64s(freqs)
+
+	lo, hi := 0.1, 10.0
+	if len(freqs) > 0 {
+		lo = math.Pow(10, math.Floor(math.Log10(freqs[0]))-2)
+		hi = math.Pow(10, math.Ceil(math.Log10(freqs[len(freqs)-1]))+2)
+	}
+	if dt > 0 {
+		nyquist := math.Pi / dt
+		if hi > nyquist {
+			hi = nyquist
+		}
+		if lo >= hi {
+			lo = hi / 1000
+		}
+	}
+
+	return LogSpace(lo, hi, n), nil
+}
+
+// transmissionZeros returns the finite transmission zeros of a square system.
+// Zeros are calculated from the zero dynamics of the system
+// with uniform relative degree k as the eigenvalues of
+// (I - B (C A^k-1 B)^-1 C A^k-1) A without the k*m eigenvalues at the origin.
+// Non-square systems or systems without uniform relative degree return nil.
+func transmissionZeros(a, b, c, d *mat.Dense) []complex128 {
+	n, _ := a.Dims()
+	_, m := b.Dims()
+	p, _ := c.Dims()
+	if m != p || n == 0 {
+		return nil
+	}
+
+	// D invertible: zeros are the eigenvalues of A - B D^-1 C
+	var dinv mat.Dense
+	if err := dinv.Inverse(d); err == nil {
+		var bd, bdc, az mat.Dense
+		bd.Mul(b, &dinv)
+		bdc.Mul(&bd, c)
+		az.Sub(a, &bdc)
+		zeros, _ := eigenvalues(&az)
+		return zeros
+	}
+
+	// C A^k-1
+	var cak mat.Dense
+	cak.CloneFrom(c)
+	for k := 1; k <= n; k++ {
+		var cakb mat.Dense
+		cakb.Mul(&cak, b)
+		if mat.Norm(&cakb, 1) > 1e-12 {
+			var inv mat.Dense
+			if err := inv.Inverse(&cakb); err != nil {
+				return nil
+			}
+			if k*m >= n {
+				return nil
+			}
+
+			// P = I - B (C A^k-1 B)^-1 C A^k-1
+			var binv, proj, az mat.Dense
+			binv.Mul(b, &inv)
+			proj.Mul(&binv, &cak)
+			proj.Scale(-1, &proj)
+			for i := 0; i < n; i++ {
+				proj.Set(i, i, proj.At(i, i)+1)
+			}
+			az.Mul(&proj, a)
+			ev, err := eigenvalues(&az)
+			if err != nil {
+				return nil
+			}
+
+			// remove the k*m eigenvalues closest to the origin
+			sort.Slice(ev, func(i, j int) bool { return cmplx.Abs(ev[i]) < cmplx.Abs(ev[j]) })
+			return ev[k*m:]
+		}
+		cak.Mul(&cak, a)
+	}
+	return nil
+}
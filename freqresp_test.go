@@ -0,0 +1,197 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// directResponse evaluates C (sI - A)^-1 B + D with a real embedding of
+// the complex linear system
+func directResponse(a, b, c, d *mat.Dense, s complex128) *mat.CDense {
+	n, _ := a.Dims()
+	_, m := b.Dims()
+	p, _ := c.Dims()
+
+	// [sr I - A, -si I; si I, sr I - A] [xr; xi] = [B; 0]
+	big := mat.NewDense(2*n, 2*n, nil)
+	rhs := mat.NewDense(2*n, m, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			big.Set(i, j, -a.At(i, j))
+			big.Set(n+i, n+j, -a.At(i, j))
+		}
+		big.Set(i, i, big.At(i, i)+real(s))
+		big.Set(n+i, n+i, big.At(n+i, n+i)+real(s))
+		big.Set(i, n+i, -imag(s))
+		big.Set(n+i, i, imag(s))
+		for j := 0; j < m; j++ {
+			rhs.Set(i, j, b.At(i, j))
+		}
+	}
+	var x mat.Dense
+	if err := x.Solve(big, rhs); err != nil {
+		panic(err)
+	}
+
+	g := mat.NewCDense(p, m, nil)
+	for i := 0; i < p; i++ {
+		for j := 0; j < m; j++ {
+			v := complex(d.At(i, j), 0)
+			for k := 0; k < n; k++ {
+				v += complex(c.At(i, k), 0) * complex(x.At(k, j), x.At(n+k, j))
+			}
+			g.Set(i, j, v)
+		}
+	}
+	return g
+}
+
+func cdenseEqualApprox(a, b *mat.CDense, tol float64) bool {
+	r, c := a.Dims()
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if cmplx.Abs(a.At(i, j)-b.At(i, j)) > tol*math.Max(1, cmplx.Abs(b.At(i, j))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestSystemFrequencyResponse(t *testing.T) {
+	sys, err := NewSystem(
+		mat.NewDense(4, 4, []float64{
+			-1, 2, 0, 0.5,
+			-2, -1, 1, 0,
+			0, 0.3, -5, 1,
+			1, 0, 0, -0.2,
+		}),
+		mat.NewDense(4, 2, []float64{
+			1, 0,
+			0, 1,
+			1, 1,
+			0, 2,
+		}),
+		mat.NewDense(3, 4, []float64{
+			1, 0, 0, 1,
+			0, 1, 1, 0,
+			1, 1, 1, 1,
+		}),
+		mat.NewDense(3, 2, []float64{
+			0, 0,
+			0.5, 0,
+			0, 1,
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	omegas := []float64{0, 0.01, 0.5, 1, 3, 100}
+	h, err := sys.FrequencyResponse(omegas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, w := range omegas {
+		expected := directResponse(sys.A, sys.B, sys.C, sys.D, complex(0, w))
+		if !cdenseEqualApprox(h[k], expected, 1e-10) {
+			fmt.Println("received:", h[k])
+			fmt.Println("expected:", expected)
+			t.Errorf("wrong frequency response at w=%v", w)
+		}
+	}
+}
+
+func TestFirstOrderBode(t *testing.T) {
+	// G(s) = 1 / (s + 1)
+	sys, _ := NewSystem(
+		mat.NewDense(1, 1, []float64{-1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	h, err := sys.FrequencyResponse([]float64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mag := MagnitudeDB(h, 0, 0)[0]; math.Abs(mag+10*math.Log10(2)) > 1e-12 {
+		t.Error("wrong magnitude:", mag)
+	}
+	if phase := Phase(h, 0, 0)[0]; math.Abs(phase+math.Pi/4) > 1e-12 {
+		t.Error("wrong phase:", phase)
+	}
+}
+
+func TestDiscreteFrequencyResponse(t *testing.T) {
+	disc, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	omegas := []float64{0.1, 1, 10, 31}
+	h, err := disc.FrequencyResponse(omegas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, w := range omegas {
+		z := cmplx.Exp(complex(0, w*disc.Dt))
+		expected := directResponse(disc.Ad, disc.Bd, disc.C, disc.D, z)
+		if !cdenseEqualApprox(h[k], expected, 1e-10) {
+			fmt.Println("received:", h[k])
+			fmt.Println("expected:", expected)
+			t.Errorf("wrong frequency response at w=%v", w)
+		}
+	}
+
+	// the pole at z=1 cannot be evaluated
+	if _, err := disc.FrequencyResponse([]float64{0}); err == nil {
+		t.Error("expected error at the pole")
+	}
+}
+
+func TestUnwrapPhase(t *testing.T) {
+	phase := UnwrapPhase([]float64{3, -3, -2, 2.5, -3.5})
+	expected := []float64{3, 2*math.Pi - 3, 2*math.Pi - 2, 2.5, 2*math.Pi - 3.5}
+	for k := range phase {
+		if math.Abs(phase[k]-expected[k]) > 1e-12 {
+			fmt.Println("received:", phase)
+			fmt.Println("expected:", expected)
+			t.Fatal("UnwrapPhase failed")
+		}
+	}
+}
+
+func TestFrequencyGrid(t *testing.T) {
+	// poles at -0.5 and -200, zero at -4
+	sys, _ := NewSystem(
+		mat.NewDense(2, 2, []float64{-0.5, 0, 0, -200}),
+		mat.NewDense(2, 1, []float64{1, 1}),
+		mat.NewDense(1, 2, []float64{3.5, 196}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	grid, err := sys.FrequencyGrid(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grid) != 100 || grid[0] > 0.005 || grid[len(grid)-1] < 20000 {
+		t.Error("grid does not cover the poles:", grid[0], grid[len(grid)-1])
+	}
+
+	zeros := transmissionZeros(sys.A, sys.B, sys.C, sys.D)
+	if len(zeros) != 1 || cmplx.Abs(zeros[0]+4) > 1e-9 {
+		t.Error("wrong zeros:", zeros)
+	}
+
+	disc, _ := sys.Discretize(0.001)
+	grid, err = disc.FrequencyGrid(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nyquist := math.Pi / 0.001; math.Abs(grid[len(grid)-1]-nyquist) > 1e-9 {
+		t.Error("discrete grid should end at the Nyquist frequency:", grid[len(grid)-1])
+	}
+}
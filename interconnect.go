@@ -0,0 +1,451 @@
+package lti
+
+import (
+	"errors"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// realization holds the matrices of a state-space model, i.e. A, B, C, D
+// of a time-continuous or A_d, B_d, C, D of a discrete system,
+// the optional signal descriptions and the input and output delays
+type realization struct {
+	a, b, c, d              *mat.Dense
+	inputs, outputs, states []Signal
+	inputDelay, outputDelay []float64
+}
+
+// dims returns the number of states, inputs and outputs
+func (r realization) dims() (n, m, p int) {
+	n, m = r.b.Dims()
+	p, _ = r.c.Dims()
+	return n, m, p
+}
+
+// system returns a time-continuous System of the realization
+func (r realization) system() (*System, error) {
+	s, err := NewSystem(r.a, r.b, r.c, r.d)
+	if err != nil {
+		return nil, err
+	}
+	s.Inputs, s.Outputs, s.States = r.inputs, r.outputs, r.states
+	s.InputDelay, s.OutputDelay = copyDelays(r.inputDelay), copyDelays(r.outputDelay)
+	return s, nil
+}
+
+// discrete returns a Discrete system with sample time dt of the realization
+func (r realization) discrete(dt float64) (*Discrete, error) {
+	if _, err := NewSystem(r.a, r.b, r.c, r.d); err != nil {
+		return nil, err
+	}
+	if r.hasDelays() {
+		return nil, ErrDelays
+	}
+	return &Discrete{
+		Ad:      r.a,
+		Bd:      r.b,
+		C:       r.c,
+		D:       r.d,
+		Dt:      dt,
+		Inputs:  r.inputs,
+		Outputs: r.outputs,
+		States:  r.states,
+	}, nil
+}
+
+// realization returns the matrices, signals and delays of the system
+func (s *System) realization() realization {
+	return realization{
+		a: s.A, b: s.B, c: s.C, d: s.D,
+		inputs:      s.Inputs,
+		outputs:     s.Outputs,
+		states:      s.States,
+		inputDelay:  s.InputDelay,
+		outputDelay: s.OutputDelay,
+	}
+}
+
+// realization returns the matrices and signals of the system
+func (d *Discrete) realization() realization {
+	return realization{
+		a: d.Ad, b: d.Bd, c: d.C, d: d.D,
+		inputs:  d.Inputs,
+		outputs: d.Outputs,
+		states:  d.States,
+	}
+}
+
+//Series connects the output of s to the input of next and returns the
+//system next * s with the inputs of s and the outputs of next.
+//The input delays of s and the output delays of next are kept; the
+//connected signals must not be delayed, see Pade.
+func (s *System) Series(next *System) (*System, error) {
+	r, err := series(s.realization(), next.realization())
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+//Parallel returns the system s + other, where both systems share the
+//inputs and their outputs are summed. Delays are kept if they are equal
+//for both systems, see Pade.
+func (s *System) Parallel(other *System) (*System, error) {
+	r, err := parallel(s.realization(), other.realization())
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+//Feedback closes the loop of s with the system k in the feedback path.
+//The outputs feedout of s are the inputs of k and the outputs of k are
+//added with sign (-1 for negative, +1 for positive feedback) to the
+//inputs feedin of s. If feedin or feedout are nil, all channels are used.
+//Outputs of k fed to the same input of s are summed.
+//The closed loop has the inputs and outputs of s. Delays in the loop are
+//not supported and return ErrDelays, see Pade.
+func (s *System) Feedback(k *System, sign float64, feedin, feedout []int) (*System, error) {
+	r, err := feedback(s.realization(), k.realization(), sign, feedin, feedout)
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+//Append returns the block diagonal system of s and others with
+//the stacked inputs, outputs, states and delays of all systems.
+func (s *System) Append(others ...*System) (*System, error) {
+	rs := []realization{s.realization()}
+	for _, o := range others {
+		rs = append(rs, o.realization())
+	}
+	return appendRealizations(rs...).system()
+}
+
+//Series connects the output of d to the input of next and returns the
+//system next * d with the inputs of d and the outputs of next.
+//Both systems must have the same sample time.
+func (d *Discrete) Series(next *Discrete) (*Discrete, error) {
+	if err := checkSampleTimes(d, next); err != nil {
+		return nil, err
+	}
+	r, err := series(d.realization(), next.realization())
+	if err != nil {
+		return nil, err
+	}
+	return r.discrete(d.Dt)
+}
+
+//Parallel returns the system d + other, where both systems share the
+//inputs and their outputs are summed. Both systems must have the same sample time.
+func (d *Discrete) Parallel(other *Discrete) (*Discrete, error) {
+	if err := checkSampleTimes(d, other); err != nil {
+		return nil, err
+	}
+	r, err := parallel(d.realization(), other.realization())
+	if err != nil {
+		return nil, err
+	}
+	return r.discrete(d.Dt)
+}
+
+//Feedback closes the loop of d with the system k in the feedback path,
+//see System.Feedback. Both systems must have the same sample time.
+func (d *Discrete) Feedback(k *Discrete, sign float64, feedin, feedout []int) (*Discrete, error) {
+	if err := checkSampleTimes(d, k); err != nil {
+		return nil, err
+	}
+	r, err := feedback(d.realization(), k.realization(), sign, feedin, feedout)
+	if err != nil {
+		return nil, err
+	}
+	return r.discrete(d.Dt)
+}
+
+//Append returns the block diagonal system of d and others with
+//the stacked inputs, outputs and states of all systems.
+//All systems must have the same sample time.
+func (d *Discrete) Append(others ...*Discrete) (*Discrete, error) {
+	rs := []realization{d.realization()}
+	for _, o := range others {
+		if err := checkSampleTimes(d, o); err != nil {
+			return nil, err
+		}
+		rs = append(rs, o.realization())
+	}
+	return appendRealizations(rs...).discrete(d.Dt)
+}
+
+// checkSampleTimes returns an error if the sample times differ
+func checkSampleTimes(ds ...*Discrete) error {
+	for _, d := range ds[1:] {
+		if d.Dt != ds[0].Dt {
+			return errors.New("sample times should be equal")
+		}
+	}
+	return nil
+}
+
+// series connects the outputs of r1 to the inputs of r2
+func series(r1, r2 realization) (realization, error) {
+	_, m1, p1 := r1.dims()
+	_, m2, p2 := r2.dims()
+	if p1 != m2 {
+		return realization{}, errors.New("outputs of the first system should match the inputs of the second system")
+	}
+	for i := 0; i < p1; i++ {
+		if delayAt(r1.outputDelay, i) != 0 || delayAt(r2.inputDelay, i) != 0 {
+			return realization{}, ErrDelays
+		}
+	}
+
+	// u = [u1; u2], y = [y1; y2]: u2 = y1, u1 = v, out = y2
+	m := mat.NewDense(m1+m2, p1+p2, nil)
+	setIdentity(m, m1, 0, p1)
+	n := mat.NewDense(m1+m2, m1, nil)
+	setIdentity(n, 0, 0, m1)
+	s := mat.NewDense(p2, p1+p2, nil)
+	setIdentity(s, 0, p1, p2)
+
+	res, err := appendRealizations(r1, r2).interconnect(m, n, s, nil)
+	if err != nil {
+		return realization{}, err
+	}
+	res.inputs = copySignals(r1.inputs)
+	res.outputs = copySignals(r2.outputs)
+	res.inputDelay, res.outputDelay = copyDelays(r1.inputDelay), copyDelays(r2.outputDelay)
+	return res, nil
+}
+
+// parallel connects r1 and r2 with common inputs and summed outputs
+func parallel(r1, r2 realization) (realization, error) {
+	_, m1, p1 := r1.dims()
+	_, m2, p2 := r2.dims()
+	if m1 != m2 || p1 != p2 {
+		return realization{}, errors.New("inputs and outputs of both systems should match")
+	}
+	if !equalDelays(r1.inputDelay, r2.inputDelay, m1) || !equalDelays(r1.outputDelay, r2.outputDelay, p1) {
+		return realization{}, ErrDelays
+	}
+
+	// u1 = u2 = v, out = y1 + y2
+	m := mat.NewDense(m1+m2, p1+p2, nil)
+	n := mat.NewDense(m1+m2, m1, nil)
+	setIdentity(n, 0, 0, m1)
+	setIdentity(n, m1, 0, m1)
+	s := mat.NewDense(p1, p1+p2, nil)
+	setIdentity(s, 0, 0, p1)
+	setIdentity(s, 0, p1, p1)
+
+	res, err := appendRealizations(r1, r2).interconnect(m, n, s, nil)
+	if err != nil {
+		return realization{}, err
+	}
+	res.inputs = copySignals(r1.inputs)
+	res.outputs = copySignals(r1.outputs)
+	res.inputDelay, res.outputDelay = copyDelays(r1.inputDelay), copyDelays(r1.outputDelay)
+	return res, nil
+}
+
+// feedback closes the loop of r with k in the feedback path
+func feedback(r, k realization, sign float64, feedin, feedout []int) (realization, error) {
+	_, mr, pr := r.dims()
+	_, mk, pk := k.dims()
+	if feedin == nil {
+		feedin = sequence(mr)
+	}
+	if feedout == nil {
+		feedout = sequence(pr)
+	}
+	if len(feedin) != pk || len(feedout) != mk {
+		return realization{}, errors.New("feedback channels should match the outputs and inputs of the feedback system")
+	}
+	if sign != 1 && sign != -1 {
+		return realization{}, errors.New("feedback sign should be -1 or +1")
+	}
+	if r.hasDelays() || k.hasDelays() {
+		return realization{}, ErrDelays
+	}
+
+	// u = [ur; uk], y = [yr; yk]: ur = v + sign * Fi yk, uk = Fo yr, out = yr
+	m := mat.NewDense(mr+mk, pr+pk, nil)
+	for i, in := range feedin {
+		if in < 0 || in >= mr {
+			return realization{}, errors.New("feedin channel out of range")
+		}
+		// duplicate channels are summed
+		m.Set(in, pr+i, m.At(in, pr+i)+sign)
+	}
+	for i, out := range feedout {
+		if out < 0 || out >= pr {
+			return realization{}, errors.New("feedout channel out of range")
+		}
+		m.Set(mr+i, out, 1)
+	}
+	n := mat.NewDense(mr+mk, mr, nil)
+	setIdentity(n, 0, 0, mr)
+	s := mat.NewDense(pr, pr+pk, nil)
+	setIdentity(s, 0, 0, pr)
+
+	res, err := appendRealizations(r, k).interconnect(m, n, s, nil)
+	if err != nil {
+		return realization{}, err
+	}
+	res.inputs = copySignals(r.inputs)
+	res.outputs = copySignals(r.outputs)
+	return res, nil
+}
+
+// appendRealizations returns the block diagonal realization
+// with the concatenated signals
+func appendRealizations(rs ...realization) realization {
+	var as, bs, cs, ds []*mat.Dense
+	var inputs, outputs, states [][]Signal
+	var ns, ms, ps []int
+	var inputDelay, outputDelay []float64
+	delayed := false
+	for _, r := range rs {
+		as = append(as, r.a)
+		bs = append(bs, r.b)
+		cs = append(cs, r.c)
+		ds = append(ds, r.d)
+		n, m, p := r.dims()
+		inputs, ms = append(inputs, r.inputs), append(ms, m)
+		outputs, ps = append(outputs, r.outputs), append(ps, p)
+		states, ns = append(states, r.states), append(ns, n)
+		for j := 0; j < m; j++ {
+			inputDelay = append(inputDelay, delayAt(r.inputDelay, j))
+		}
+		for i := 0; i < p; i++ {
+			outputDelay = append(outputDelay, delayAt(r.outputDelay, i))
+		}
+		delayed = delayed || r.hasDelays()
+	}
+	if !delayed {
+		inputDelay, outputDelay = nil, nil
+	}
+	return realization{
+		a:           blockDiag(as...),
+		b:           blockDiag(bs...),
+		c:           blockDiag(cs...),
+		d:           blockDiag(ds...),
+		inputs:      concatSignals(inputs, ms),
+		outputs:     concatSignals(outputs, ps),
+		states:      concatSignals(states, ns),
+		inputDelay:  inputDelay,
+		outputDelay: outputDelay,
+	}
+}
+
+// interconnect closes the static interconnection u = M y + N v of the
+// inputs u and outputs y of r with the new inputs v and the new outputs
+// out = S y + T v. T may be nil. The states and their signals are kept,
+// the input and output signals and delays must be set by the caller.
+//
+// With E = I - M D, which must be invertible for a well-posed interconnection,
+// u = E^-1 (M C x + N v) and
+//  A_cl = A + B E^-1 M C,     B_cl = B E^-1 N,
+//  C_cl = S (C + D E^-1 M C), D_cl = S D E^-1 N + T
+func (r realization) interconnect(m, n, s, t *mat.Dense) (realization, error) {
+	_, mu, _ := r.dims()
+
+	// E = I - M D
+	var e, einv mat.Dense
+	e.Mul(m, r.d)
+	e.Scale(-1, &e)
+	for i := 0; i < mu; i++ {
+		e.Set(i, i, e.At(i, i)+1)
+	}
+	if err := einv.Inverse(&e); err != nil {
+		return realization{}, errors.New("algebraic loop is not well-posed")
+	}
+
+	// K = E^-1 M C, L = E^-1 N
+	var emc, k, l mat.Dense
+	emc.Mul(&einv, m)
+	k.Mul(&emc, r.c)
+	l.Mul(&einv, n)
+
+	var a, b, bk, y, dk, c, dl, d mat.Dense
+	bk.Mul(r.b, &k)
+	a.Add(r.a, &bk)
+	b.Mul(r.b, &l)
+	dk.Mul(r.d, &k)
+	y.Add(r.c, &dk)
+	c.Mul(s, &y)
+	dl.Mul(r.d, &l)
+	d.Mul(s, &dl)
+	if t != nil {
+		d.Add(&d, t)
+	}
+
+	return realization{a: &a, b: &b, c: &c, d: &d, states: copySignals(r.states)}, nil
+}
+
+// hasDelays returns true if any input or output of r is delayed
+func (r realization) hasDelays() bool {
+	for _, delays := range [][]float64{r.inputDelay, r.outputDelay} {
+		for _, tau := range delays {
+			if tau != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copyDelays returns a copy of the delays or nil if no channel is delayed
+func copyDelays(delays []float64) []float64 {
+	for _, tau := range delays {
+		if tau != 0 {
+			return append([]float64(nil), delays...)
+		}
+	}
+	return nil
+}
+
+// equalDelays returns true if the delays of the n channels are equal
+func equalDelays(d1, d2 []float64, n int) bool {
+	for i := 0; i < n; i++ {
+		if delayAt(d1, i) != delayAt(d2, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// blockDiag returns the block diagonal matrix of ms
+func blockDiag(ms ...*mat.Dense) *mat.Dense {
+	rows, cols := 0, 0
+	for _, m := range ms {
+		r, c := m.Dims()
+		rows += r
+		cols += c
+	}
+	res := mat.NewDense(rows, cols, nil)
+	i, j := 0, 0
+	for _, m := range ms {
+		r, c := m.Dims()
+		res.Slice(i, i+r, j, j+c).(*mat.Dense).Copy(m)
+		i += r
+		j += c
+	}
+	return res
+}
+
+// setIdentity sets the n x n block of m at (i, j) to the identity
+func setIdentity(m *mat.Dense, i, j, n int) {
+	for k := 0; k < n; k++ {
+		m.Set(i+k, j+k, 1)
+	}
+}
+
+// sequence returns the indices 0, ..., n-1
+func sequence(n int) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
+}
@@ -0,0 +1,181 @@
+package lti
+
+import (
+	"fmt"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// newTestFirstOrder returns k / (s + a) + d
+func newTestFirstOrder(k, a, d float64) *System {
+	sys, _ := NewSystem(
+		mat.NewDense(1, 1, []float64{-a}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{k}),
+		mat.NewDense(1, 1, []float64{d}),
+	)
+	return sys
+}
+
+// sisoAt evaluates the SISO system at the angular frequency w
+func sisoAt(t *testing.T, sys FrequencyResponder, w float64) complex128 {
+	h, err := sys.FrequencyResponse([]float64{w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h[0].At(0, 0)
+}
+
+func TestSeriesParallelFeedback(t *testing.T) {
+	g1 := newTestFirstOrder(2, 1, 0.5)
+	g2 := newTestFirstOrder(1, 3, 0)
+
+	series, err := g1.Series(g2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := g1.Parallel(g2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	negative, err := g1.Feedback(g2, -1, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	positive, err := g1.Feedback(g2, 1, []int{0}, []int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, w := range []float64{0, 0.3, 1, 10} {
+		h1, h2 := sisoAt(t, g1, w), sisoAt(t, g2, w)
+		var config = []struct {
+			Name     string
+			Received complex128
+			Expected complex128
+		}{
+			{Name: "series", Received: sisoAt(t, series, w), Expected: h1 * h2},
+			{Name: "parallel", Received: sisoAt(t, parallel, w), Expected: h1 + h2},
+			{Name: "negative feedback", Received: sisoAt(t, negative, w), Expected: h1 / (1 + h1*h2)},
+			{Name: "positive feedback", Received: sisoAt(t, positive, w), Expected: h1 / (1 - h1*h2)},
+		}
+		for _, cfg := range config {
+			if cmplx.Abs(cfg.Received-cfg.Expected) > 1e-12 {
+				fmt.Println("received:", cfg.Received)
+				fmt.Println("expected:", cfg.Expected)
+				t.Errorf("%s failed at w=%v", cfg.Name, w)
+			}
+		}
+	}
+}
+
+func TestFeedbackChannels(t *testing.T) {
+	// two-input two-output plant, feedback from output 1 to input 0
+	plant, _ := NewSystem(
+		mat.NewDense(2, 2, []float64{-1, 0, 0, -2}),
+		mat.NewDense(2, 2, []float64{1, 0, 0, 1}),
+		mat.NewDense(2, 2, []float64{1, 0, 1, 1}),
+		mat.NewDense(2, 2, []float64{0, 0, 0, 0}),
+	)
+	k := newTestFirstOrder(0, 1, 3) // static gain 3
+
+	cl, err := plant.Feedback(k, -1, []int{0}, []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, c := cl.D.Dims()
+	if r != 2 || c != 2 {
+		t.Fatal("closed loop should keep the plant inputs and outputs")
+	}
+
+	// closed loop x1' = -x1 + u0 - 3 (x1 + x2), x2' = -2 x2 + u1
+	// and the unobservable state of k driven by x1 + x2
+	expected := mat.NewDense(3, 3, []float64{
+		-4, -3, 0,
+		0, -2, 0,
+		1, 1, -1,
+	})
+	if !mat.EqualApprox(cl.A, expected, 1e-12) {
+		fmt.Println("received:", mat.Formatted(cl.A))
+		t.Error("wrong closed loop matrix")
+	}
+
+	if _, err := plant.Feedback(k, -1, []int{0}, []int{2}); err == nil {
+		t.Error("expected error for invalid channel")
+	}
+	if _, err := plant.Feedback(k, 2, []int{0}, []int{1}); err == nil {
+		t.Error("expected error for invalid sign")
+	}
+
+	// both outputs of the static gain diag(2, 3) are fed back to input 0
+	gains, _ := NewSystem(
+		mat.NewDense(1, 1, []float64{-1}),
+		mat.NewDense(1, 2, nil),
+		mat.NewDense(2, 1, nil),
+		mat.NewDense(2, 2, []float64{2, 0, 0, 3}),
+	)
+	cl, err = plant.Feedback(gains, -1, []int{0, 0}, []int{0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// x1' = -x1 + u0 - (2 + 3) x1
+	if cl.A.At(0, 0) != -6 || cl.A.At(0, 1) != 0 {
+		fmt.Println("received:", mat.Formatted(cl.A))
+		t.Error("duplicate feedback channels should be summed")
+	}
+}
+
+func TestAppend(t *testing.T) {
+	g1 := newTestFirstOrder(2, 1, 0.5)
+	g2 := newTestFirstOrder(1, 3, 0)
+
+	sys, err := g1.Append(g2, g1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := sys.FrequencyResponse([]float64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmplx.Abs(h[0].At(1, 1)-sisoAt(t, g2, 1)) > 1e-12 || h[0].At(0, 1) != 0 || h[0].At(2, 0) != 0 {
+		t.Error("Append returned wrong block diagonal system")
+	}
+}
+
+func TestAlgebraicLoop(t *testing.T) {
+	g := newTestFirstOrder(1, 1, 1)
+	k := newTestFirstOrder(1, 1, 1)
+	if _, err := g.Feedback(k, 1, nil, nil); err == nil {
+		t.Error("expected error for ill-posed algebraic loop")
+	}
+	if _, err := g.Feedback(k, -1, nil, nil); err != nil {
+		t.Error("well-posed algebraic loop failed:", err)
+	}
+}
+
+func TestDiscreteInterconnection(t *testing.T) {
+	d1, _ := newTestFirstOrder(2, 1, 0.5).Discretize(0.1)
+	d2, _ := newTestFirstOrder(1, 3, 0).Discretize(0.1)
+	d3, _ := newTestFirstOrder(1, 3, 0).Discretize(0.2)
+
+	cl, err := d1.Feedback(d2, -1, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cl.Dt != 0.1 {
+		t.Error("sample time not preserved")
+	}
+	h1, h2 := sisoAt(t, d1, 2), sisoAt(t, d2, 2)
+	if cmplx.Abs(sisoAt(t, cl, 2)-h1/(1+h1*h2)) > 1e-12 {
+		t.Error("discrete feedback failed")
+	}
+
+	if _, err := d1.Series(d3); err == nil {
+		t.Error("expected error for different sample times")
+	}
+	if _, err := d1.Append(d2, d3); err == nil {
+		t.Error("expected error for different sample times")
+	}
+}
@@ -0,0 +1,225 @@
+package lti
+
+import (
+	"errors"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//LFT returns the lower linear fractional transformation of the generalized
+//plant p with the controller k. The last nu inputs u of p are driven by the
+//outputs of k and the last ny outputs y of p are the inputs of k, i.e.
+// 	[z; y] = P [w; u], u = K y
+//The closed loop maps the remaining inputs w onto the remaining outputs z.
+//Systems with delays return ErrDelays, see Pade.
+func LFT(p, k *System, nu, ny int) (*System, error) {
+	r, err := lowerLFT(p.realization(), k.realization(), nu, ny)
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+//UpperLFT returns the upper linear fractional transformation of p with the
+//uncertainty (or any other system) delta. The first nw inputs w of p are
+//driven by the outputs of delta and the first nz outputs z of p are the
+//inputs of delta, i.e.
+// 	[z; y] = P [w; u], w = Delta z
+//The closed loop maps the remaining inputs u onto the remaining outputs y.
+func UpperLFT(p, delta *System, nw, nz int) (*System, error) {
+	r, err := upperLFT(p.realization(), delta.realization(), nw, nz)
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+//LFTDiscrete returns the lower linear fractional transformation of the
+//discrete plant p with the controller k, see LFT.
+//Both systems must have the same sample time.
+func LFTDiscrete(p, k *Discrete, nu, ny int) (*Discrete, error) {
+	if err := checkSampleTimes(p, k); err != nil {
+		return nil, err
+	}
+	r, err := lowerLFT(p.realization(), k.realization(), nu, ny)
+	if err != nil {
+		return nil, err
+	}
+	return r.discrete(p.Dt)
+}
+
+//UpperLFTDiscrete returns the upper linear fractional transformation of the
+//discrete plant p with delta, see UpperLFT.
+//Both systems must have the same sample time.
+func UpperLFTDiscrete(p, delta *Discrete, nw, nz int) (*Discrete, error) {
+	if err := checkSampleTimes(p, delta); err != nil {
+		return nil, err
+	}
+	r, err := upperLFT(p.realization(), delta.realization(), nw, nz)
+	if err != nil {
+		return nil, err
+	}
+	return r.discrete(p.Dt)
+}
+
+//MixedSensitivity returns the generalized plant of the mixed sensitivity
+//problem for the nominal plant g (p outputs, m inputs) with the weights
+//ws (p x p) on the error, wu (m x m) on the control signal and wt (p x p)
+//on the plant output. Weights may be nil if they are not used.
+//
+//The generalized plant has the inputs [w; u] with the reference w and the
+//control signal u and the outputs [z_s; z_u; z_t; e] with
+// 	e = w - G u, z_s = Ws e, z_u = Wu u, z_t = Wt G u
+//Closing the loop with LFT(P, K, m, p) gives z_s = Ws S w, z_u = Wu K S w
+//and z_t = Wt T w for the controller u = K e.
+//Plants and weights with delays return ErrDelays, see Pade.
+func MixedSensitivity(g, ws, wu, wt *System) (*System, error) {
+	p, m := g.D.Dims()
+
+	names := func(prefix string, n int) []string {
+		res := make([]string, n)
+		for i := range res {
+			res[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return res
+	}
+	signals := func(names []string) []Signal {
+		res := make([]Signal, len(names))
+		for i, name := range names {
+			res[i] = Signal{Name: name}
+		}
+		return res
+	}
+	w, u, y, e := names("w", p), names("u", m), names("y", p), names("e", p)
+
+	plant := g.realization()
+	plant.inputs, plant.outputs = signals(u), signals(y)
+	rs := []realization{plant}
+	outputs := []string{}
+
+	weights := []struct {
+		sys    *System
+		input  []string
+		prefix string
+		size   int
+	}{
+		{sys: ws, input: e, prefix: "zs", size: p},
+		{sys: wu, input: u, prefix: "zu", size: m},
+		{sys: wt, input: y, prefix: "zt", size: p},
+	}
+	for _, wgt := range weights {
+		if wgt.sys == nil {
+			continue
+		}
+		po, mi := wgt.sys.D.Dims()
+		if mi != wgt.size {
+			return nil, fmt.Errorf("MixedSensitivity: weight %s should have %d inputs", wgt.prefix, wgt.size)
+		}
+		r := wgt.sys.realization()
+		z := names(wgt.prefix, po)
+		r.inputs, r.outputs = signals(wgt.input), signals(z)
+		rs = append(rs, r)
+		outputs = append(outputs, z...)
+	}
+	if len(rs) == 1 {
+		return nil, errors.New("MixedSensitivity: at least one weight is required")
+	}
+	outputs = append(outputs, e...)
+
+	sums := make([]Sum, p)
+	for i := range sums {
+		sums[i] = Sum{Output: e[i], Inputs: []string{w[i], y[i]}, Signs: []float64{1, -1}}
+	}
+
+	r, err := connect(rs, append(w, u...), outputs, sums)
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+// lowerLFT closes the last nu inputs and ny outputs of p with k
+func lowerLFT(p, k realization, nu, ny int) (realization, error) {
+	_, mp, pp := p.dims()
+	if nu < 0 || nu > mp || ny < 0 || ny > pp {
+		return realization{}, errors.New("LFT: nu and ny should not exceed the inputs and outputs of the plant")
+	}
+	return lft(p, k, rangeOf(mp-nu, mp), rangeOf(pp-ny, pp))
+}
+
+// upperLFT closes the first nw inputs and nz outputs of p with delta
+func upperLFT(p, delta realization, nw, nz int) (realization, error) {
+	_, mp, pp := p.dims()
+	if nw < 0 || nw > mp || nz < 0 || nz > pp {
+		return realization{}, errors.New("LFT: nw and nz should not exceed the inputs and outputs of the plant")
+	}
+	return lft(p, delta, rangeOf(0, nw), rangeOf(0, nz))
+}
+
+// lft drives the inputs in of p by the outputs of k and feeds the
+// outputs out of p to the inputs of k; the remaining channels of p
+// are the inputs and outputs of the closed loop
+func lft(p, k realization, in, out []int) (realization, error) {
+	_, mp, pp := p.dims()
+	_, mk, pk := k.dims()
+	if len(in) != pk || len(out) != mk {
+		return realization{}, errors.New("LFT: closed channels should match the outputs and inputs of the feedback system")
+	}
+	if p.hasDelays() || k.hasDelays() {
+		return realization{}, ErrDelays
+	}
+	freeIn, freeOut := complement(in, mp), complement(out, pp)
+	if len(freeIn) == 0 || len(freeOut) == 0 {
+		return realization{}, errors.New("LFT: closed loop has no inputs or outputs")
+	}
+
+	// u = [up; uk], y = [yp; yk]: up[in] = yk, up[free] = v, uk = yp[out], z = yp[free]
+	m := mat.NewDense(mp+mk, pp+pk, nil)
+	for i, ch := range in {
+		m.Set(ch, pp+i, 1)
+	}
+	for i, ch := range out {
+		m.Set(mp+i, ch, 1)
+	}
+	n := mat.NewDense(mp+mk, len(freeIn), nil)
+	for j, ch := range freeIn {
+		n.Set(ch, j, 1)
+	}
+	s := mat.NewDense(len(freeOut), pp+pk, nil)
+	for j, ch := range freeOut {
+		s.Set(j, ch, 1)
+	}
+
+	res, err := appendRealizations(p, k).interconnect(m, n, s, nil)
+	if err != nil {
+		return realization{}, err
+	}
+	res.inputs = selectSignals(p.inputs, freeIn)
+	res.outputs = selectSignals(p.outputs, freeOut)
+	return res, nil
+}
+
+// rangeOf returns the indices from, ..., to-1
+func rangeOf(from, to int) []int {
+	idx := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		idx = append(idx, i)
+	}
+	return idx
+}
+
+// complement returns the indices 0, ..., n-1 which are not in idx
+func complement(idx []int, n int) []int {
+	used := make([]bool, n)
+	for _, i := range idx {
+		used[i] = true
+	}
+	var res []int
+	for i := 0; i < n; i++ {
+		if !used[i] {
+			res = append(res, i)
+		}
+	}
+	return res
+}
@@ -0,0 +1,89 @@
+package lti
+
+import (
+	"fmt"
+	"math/cmplx"
+	"testing"
+)
+
+func TestLFT(t *testing.T) {
+	g1 := newTestFirstOrder(2, 1, 0)
+	g2 := newTestFirstOrder(1, 3, 0.5)
+	g1.Inputs = []Signal{{Name: "v"}}
+	g1.Outputs = []Signal{{Name: "z"}}
+	g2.Inputs = []Signal{{Name: "v"}}
+	g2.Outputs = []Signal{{Name: "y"}}
+
+	// z = G1 (w + u), y = G2 (w + u)
+	p, err := Connect([]*System{g1, g2}, []string{"w", "u"}, []string{"z", "y"},
+		Sum{Output: "v", Inputs: []string{"w", "u"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := newTestFirstOrder(0.5, 2, 0.1)
+
+	lower, err := LFT(p, k, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upper, err := UpperLFT(p, k, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lower.Inputs[0].Name != "w" || lower.Outputs[0].Name != "z" ||
+		upper.Inputs[0].Name != "u" || upper.Outputs[0].Name != "y" {
+		t.Error("LFT returned wrong signals")
+	}
+
+	for _, w := range []float64{0, 0.5, 4} {
+		h1, h2, hk := sisoAt(t, g1, w), sisoAt(t, g2, w), sisoAt(t, k, w)
+		if l, e := sisoAt(t, lower, w), h1/(1-hk*h2); cmplx.Abs(l-e) > 1e-12 {
+			fmt.Println("received:", l, "expected:", e)
+			t.Error("wrong lower LFT")
+		}
+		if u, e := sisoAt(t, upper, w), h2/(1-hk*h1); cmplx.Abs(u-e) > 1e-12 {
+			fmt.Println("received:", u, "expected:", e)
+			t.Error("wrong upper LFT")
+		}
+	}
+
+	if _, err := LFT(p, k, 2, 1); err == nil {
+		t.Error("expected error for wrong number of channels")
+	}
+}
+
+func TestMixedSensitivity(t *testing.T) {
+	g := newTestFirstOrder(3, 1, 0)
+	ws := newTestFirstOrder(0.5, 0.01, 0.1)
+	wt := newTestFirstOrder(10, 100, 1)
+	k := newTestFirstOrder(4, 0.5, 1)
+
+	p, err := MixedSensitivity(g, ws, nil, wt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, m := p.D.Dims(); m != 2 {
+		t.Fatal("generalized plant should have 2 inputs")
+	}
+	cl, err := LFT(p, k, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := 0.8
+	h, err := cl.FrequencyResponse([]float64{w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := sisoAt(t, g, w) * sisoAt(t, k, w)
+	sens, comp := 1/(1+l), l/(1+l)
+	if cmplx.Abs(h[0].At(0, 0)-sisoAt(t, ws, w)*sens) > 1e-12 ||
+		cmplx.Abs(h[0].At(1, 0)-sisoAt(t, wt, w)*comp) > 1e-12 {
+		fmt.Println("received:", h[0])
+		t.Error("wrong mixed sensitivity closed loop")
+	}
+
+	if _, err := MixedSensitivity(g, nil, nil, nil); err == nil {
+		t.Error("expected error without weights")
+	}
+}
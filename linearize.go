@@ -0,0 +1,353 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//Func is a nonlinear function of the state x and the input u, e.g. the
+//state equation x' = f(x, u) or the output equation y = h(x, u)
+type Func func(x, u []float64) []float64
+
+//ComplexFunc is the complex extension of a Func for complex-step differentiation
+type ComplexFunc func(x, u []complex128) []complex128
+
+//DifferenceMethod selects the numerical differentiation of Linearize
+type DifferenceMethod int
+
+const (
+	//CentralDifference approximates the derivatives by (f(x+h) - f(x-h)) / 2h
+	CentralDifference DifferenceMethod = iota
+	//ComplexStep calculates the derivatives by Im(f(x+ih)) / h, which is
+	//exact up to rounding errors but needs the complex extensions of f and h
+	ComplexStep
+)
+
+//LinearizeOptions contains the options of Linearize.
+//
+// The fields are:
+// 	Method:   CentralDifference (default) or ComplexStep
+// 	Step:     Relative step size h (default: eps^(1/3) for central differences, 1e-20 for complex steps)
+// 	ComplexF: Complex extension of f, required for ComplexStep
+// 	ComplexH: Complex extension of h, required for ComplexStep
+//
+type LinearizeOptions struct {
+	Method   DifferenceMethod
+	Step     float64
+	ComplexF ComplexFunc
+	ComplexH ComplexFunc
+}
+
+//TrimOptions contains the options of Trim.
+//
+// The fields are:
+// 	FreeStates:    Indices of the states which are adjusted (nil for all states)
+// 	FreeInputs:    Indices of the inputs which are adjusted (nil for none)
+// 	Tolerance:     Tolerance of the norm of f (default: 1e-10)
+// 	MaxIterations: Maximum number of iterations (default: 100)
+//
+type TrimOptions struct {
+	FreeStates    []int
+	FreeInputs    []int
+	Tolerance     float64
+	MaxIterations int
+}
+
+//Linearize returns the linearization x' = A x + B u, y = C x + D u of the
+//nonlinear system x' = f(x, u), y = h(x, u) at the operating point x0, u0.
+//The operating point with y0 = h(x0, u0) and dx0 = f(x0, u0) is attached
+//to the returned system. If opts is nil, central differences are used.
+//A model without inputs is linearized with an empty u0 and gives empty
+//matrices B and D, a model without outputs gives empty matrices C and D.
+func Linearize(f, h Func, x0, u0 *mat.VecDense, opts *LinearizeOptions) (*System, error) {
+	if opts == nil {
+		opts = &LinearizeOptions{}
+	}
+	if x0 == nil || u0 == nil {
+		return nil, errors.New("Linearize: x0 and u0 are required")
+	}
+	x, u := vecData(x0), vecData(u0)
+	if len(x) == 0 {
+		return nil, errors.New("Linearize: x0 should not be empty")
+	}
+	dx0, y0 := f(x, u), h(x, u)
+	if len(dx0) != len(x) {
+		return nil, errors.New("Linearize: f should return a vector of the length of the state")
+	}
+
+	var a, b, c, d *mat.Dense
+	switch opts.Method {
+	case CentralDifference:
+		step := opts.Step
+		if step == 0 {
+			step = math.Cbrt(machineEpsilon)
+		}
+		var err error
+		if a, b, err = centralJacobians(f, x, u, len(dx0), step); err != nil {
+			return nil, err
+		}
+		if c, d, err = centralJacobians(h, x, u, len(y0), step); err != nil {
+			return nil, err
+		}
+	case ComplexStep:
+		if opts.ComplexF == nil || opts.ComplexH == nil {
+			return nil, errors.New("Linearize: complex step needs ComplexF and ComplexH")
+		}
+		step := opts.Step
+		if step == 0 {
+			step = 1e-20
+		}
+		var err error
+		if a, b, err = complexJacobians(opts.ComplexF, x, u, len(dx0), step); err != nil {
+			return nil, err
+		}
+		if c, d, err = complexJacobians(opts.ComplexH, x, u, len(y0), step); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("Linearize: unknown difference method")
+	}
+
+	// NewSystem cannot check the dimensions of empty matrices
+	sys := &System{A: a, B: b, C: c, D: d}
+	if len(u) > 0 && len(y0) > 0 {
+		var err error
+		if sys, err = NewSystem(a, b, c, d); err != nil {
+			return nil, err
+		}
+	}
+	sys.X0 = newVec(x)
+	sys.U0 = newVec(u)
+	sys.Y0 = newVec(y0)
+	sys.Dx0 = newVec(dx0)
+	return sys, nil
+}
+
+//Trim searches a trim point x, u with f(x, u) = 0 starting from x0, u0
+//by a damped Gauss-Newton (Levenberg-Marquardt) iteration. Only the free
+//states and inputs given by opts are adjusted, all others keep their initial
+//values. If opts is nil, all states are adjusted for the fixed input u0.
+func Trim(f Func, x0, u0 *mat.VecDense, opts *TrimOptions) (*mat.VecDense, *mat.VecDense, error) {
+	if opts == nil {
+		opts = &TrimOptions{}
+	}
+	tol := opts.Tolerance
+	if tol == 0 {
+		tol = 1e-10
+	}
+	maxIter := opts.MaxIterations
+	if maxIter == 0 {
+		maxIter = 100
+	}
+	if x0 == nil || u0 == nil {
+		return nil, nil, errors.New("Trim: x0 and u0 are required")
+	}
+	x, u := vecData(x0), vecData(u0)
+	freeStates := opts.FreeStates
+	if freeStates == nil {
+		freeStates = sequence(len(x))
+	}
+
+	// z are the free variables: states followed by inputs
+	type variable struct {
+		v   []float64
+		idx int
+	}
+	var vars []variable
+	for _, i := range freeStates {
+		if i < 0 || i >= len(x) {
+			return nil, nil, errors.New("Trim: free state out of range")
+		}
+		vars = append(vars, variable{x, i})
+	}
+	for _, i := range opts.FreeInputs {
+		if i < 0 || i >= len(u) {
+			return nil, nil, errors.New("Trim: free input out of range")
+		}
+		vars = append(vars, variable{u, i})
+	}
+	if len(vars) == 0 {
+		return nil, nil, errors.New("Trim: no free variables")
+	}
+
+	errLength := errors.New("Trim: f should return a vector of the length of the state")
+	residual := func() (*mat.VecDense, float64) {
+		r := f(x, u)
+		if len(r) != len(x) {
+			return nil, math.NaN()
+		}
+		v := mat.NewVecDense(len(r), r)
+		return v, mat.Norm(v, 2)
+	}
+	r, norm := residual()
+	if r == nil {
+		return nil, nil, errLength
+	}
+
+	nz := len(vars)
+	lambda := 1e-3
+	step := math.Sqrt(machineEpsilon)
+	jac := mat.NewDense(r.Len(), nz, nil)
+	for iter := 0; iter < maxIter && norm > tol; iter++ {
+		// forward difference Jacobian of the free variables
+		for j, vr := range vars {
+			old := vr.v[vr.idx]
+			hj := step * math.Max(1, math.Abs(old))
+			vr.v[vr.idx] = old + hj
+			rj := f(x, u)
+			vr.v[vr.idx] = old
+			if len(rj) != len(x) {
+				return nil, nil, errLength
+			}
+			for i := range rj {
+				jac.Set(i, j, (rj[i]-r.AtVec(i))/hj)
+			}
+		}
+
+		// (J^T J + lambda diag(J^T J)) dz = -J^T r
+		var jtj mat.Dense
+		var jtr mat.VecDense
+		jtj.Mul(jac.T(), jac)
+		jtr.MulVec(jac.T(), r)
+		accepted := false
+		for k := 0; k < 30 && !accepted; k++ {
+			var lhs mat.Dense
+			lhs.CloneFrom(&jtj)
+			for i := 0; i < nz; i++ {
+				lhs.Set(i, i, jtj.At(i, i)*(1+lambda)+lambda*1e-12)
+			}
+			var dz mat.VecDense
+			if err := dz.SolveVec(&lhs, &jtr); err != nil {
+				lambda *= 10
+				continue
+			}
+			old := make([]float64, nz)
+			for j, vr := range vars {
+				old[j] = vr.v[vr.idx]
+				vr.v[vr.idx] -= dz.AtVec(j)
+			}
+			rn, nn := residual()
+			if rn == nil {
+				return nil, nil, errLength
+			}
+			if nn < norm {
+				r, norm = rn, nn
+				lambda = math.Max(lambda/10, 1e-12)
+				accepted = true
+			} else {
+				for j, vr := range vars {
+					vr.v[vr.idx] = old[j]
+				}
+				lambda *= 10
+			}
+		}
+		if !accepted {
+			break
+		}
+	}
+	if norm > tol {
+		return nil, nil, errors.New("Trim: no trim point found")
+	}
+	return newVec(x), newVec(u), nil
+}
+
+// centralJacobians returns the Jacobians of g with respect to x and u
+// by central differences with the relative step size step
+func centralJacobians(g Func, x, u []float64, rows int, step float64) (*mat.Dense, *mat.Dense, error) {
+	jacobian := func(v []float64) (*mat.Dense, error) {
+		jac := newJacobian(rows, len(v))
+		for j := range v {
+			old := v[j]
+			h := step * math.Max(1, math.Abs(old))
+			v[j] = old + h
+			plus := g(x, u)
+			v[j] = old - h
+			minus := g(x, u)
+			v[j] = old
+			if len(plus) != rows || len(minus) != rows {
+				return nil, errors.New("Linearize: function returns wrong number of values")
+			}
+			for i := 0; i < rows; i++ {
+				jac.Set(i, j, (plus[i]-minus[i])/(2*h))
+			}
+		}
+		return jac, nil
+	}
+	jx, err := jacobian(x)
+	if err != nil {
+		return nil, nil, err
+	}
+	ju, err := jacobian(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	return jx, ju, nil
+}
+
+// complexJacobians returns the Jacobians of g with respect to x and u
+// by complex steps with the relative step size step
+func complexJacobians(g ComplexFunc, x, u []float64, rows int, step float64) (*mat.Dense, *mat.Dense, error) {
+	cx, cu := toComplex(x), toComplex(u)
+	jacobian := func(v []complex128) (*mat.Dense, error) {
+		jac := newJacobian(rows, len(v))
+		for j := range v {
+			old := v[j]
+			h := step * math.Max(1, math.Abs(real(old)))
+			v[j] = old + complex(0, h)
+			res := g(cx, cu)
+			v[j] = old
+			if len(res) != rows {
+				return nil, errors.New("Linearize: complex extension returns wrong number of values")
+			}
+			for i := 0; i < rows; i++ {
+				jac.Set(i, j, imag(res[i])/h)
+			}
+		}
+		return jac, nil
+	}
+	jx, err := jacobian(cx)
+	if err != nil {
+		return nil, nil, err
+	}
+	ju, err := jacobian(cu)
+	if err != nil {
+		return nil, nil, err
+	}
+	return jx, ju, nil
+}
+
+// newJacobian returns a zero rows x cols matrix, which is empty if rows or
+// cols is zero
+func newJacobian(rows, cols int) *mat.Dense {
+	if rows == 0 || cols == 0 {
+		return &mat.Dense{}
+	}
+	return mat.NewDense(rows, cols, nil)
+}
+
+// vecData returns a copy of the elements of v, nil for an empty v
+func vecData(v *mat.VecDense) []float64 {
+	if v.IsEmpty() {
+		return nil
+	}
+	return mat.Col(nil, 0, v)
+}
+
+// newVec returns a vector of the elements of v, an empty vector for an empty v
+func newVec(v []float64) *mat.VecDense {
+	if len(v) == 0 {
+		return &mat.VecDense{}
+	}
+	return mat.NewVecDense(len(v), v)
+}
+
+// toComplex converts a real vector into a complex vector
+func toComplex(v []float64) []complex128 {
+	res := make([]complex128, len(v))
+	for i, x := range v {
+		res[i] = complex(x, 0)
+	}
+	return res
+}
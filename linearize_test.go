@@ -0,0 +1,143 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// pendulum returns x1' = x2, x2' = -sin(x1) + u and y = x1 * u
+func pendulum() (Func, Func, ComplexFunc, ComplexFunc) {
+	f := func(x, u []float64) []float64 { return []float64{x[1], -math.Sin(x[0]) + u[0]} }
+	h := func(x, u []float64) []float64 { return []float64{x[0] * u[0]} }
+	cf := func(x, u []complex128) []complex128 { return []complex128{x[1], -cmplx.Sin(x[0]) + u[0]} }
+	ch := func(x, u []complex128) []complex128 { return []complex128{x[0] * u[0]} }
+	return f, h, cf, ch
+}
+
+func TestLinearize(t *testing.T) {
+	f, h, cf, ch := pendulum()
+	x0 := mat.NewVecDense(2, []float64{0.3, 0.1})
+	u0 := mat.NewVecDense(1, []float64{0.2})
+
+	a := mat.NewDense(2, 2, []float64{0, 1, -math.Cos(0.3), 0})
+	b := mat.NewDense(2, 1, []float64{0, 1})
+	c := mat.NewDense(1, 2, []float64{0.2, 0})
+	d := mat.NewDense(1, 1, []float64{0.3})
+
+	var config = []struct {
+		Name string
+		Opts *LinearizeOptions
+		Tol  float64
+	}{
+		{Name: "central", Opts: nil, Tol: 1e-9},
+		{Name: "complex", Opts: &LinearizeOptions{Method: ComplexStep, ComplexF: cf, ComplexH: ch}, Tol: 1e-15},
+	}
+	for _, cfg := range config {
+		sys, err := Linearize(f, h, x0, u0, cfg.Opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mat.EqualApprox(sys.A, a, cfg.Tol) || !mat.EqualApprox(sys.B, b, cfg.Tol) ||
+			!mat.EqualApprox(sys.C, c, cfg.Tol) || !mat.EqualApprox(sys.D, d, cfg.Tol) {
+			fmt.Println("received:", sys.A, sys.B, sys.C, sys.D)
+			t.Error("Linearize returned wrong matrices for", cfg.Name)
+		}
+		if math.Abs(sys.Dx0.AtVec(1)-(-math.Sin(0.3)+0.2)) > 1e-15 || math.Abs(sys.Y0.AtVec(0)-0.06) > 1e-15 {
+			t.Error("Linearize returned wrong operating point for", cfg.Name)
+		}
+	}
+
+	if _, err := Linearize(f, h, x0, u0, &LinearizeOptions{Method: ComplexStep}); err == nil {
+		t.Error("expected error for complex step without complex functions")
+	}
+
+	// h drops its output away from the operating point
+	short := func(x, u []float64) []float64 {
+		if x[0] != 0.3 {
+			return nil
+		}
+		return h(x, u)
+	}
+	if _, err := Linearize(f, short, x0, u0, nil); err == nil {
+		t.Error("expected error for wrong number of values")
+	}
+}
+
+func TestLinearizeWithoutInputs(t *testing.T) {
+	// x1' = x2, x2' = -sin(x1) without inputs and with y = x1 or no outputs
+	f := func(x, u []float64) []float64 { return []float64{x[1], -math.Sin(x[0])} }
+	h := func(x, u []float64) []float64 { return []float64{x[0]} }
+	none := func(x, u []float64) []float64 { return nil }
+	cf := func(x, u []complex128) []complex128 { return []complex128{x[1], -cmplx.Sin(x[0])} }
+	ch := func(x, u []complex128) []complex128 { return []complex128{x[0]} }
+	x0 := mat.NewVecDense(2, []float64{0.3, 0})
+	a := mat.NewDense(2, 2, []float64{0, 1, -math.Cos(0.3), 0})
+
+	for _, opts := range []*LinearizeOptions{nil, {Method: ComplexStep, ComplexF: cf, ComplexH: ch}} {
+		sys, err := Linearize(f, h, x0, &mat.VecDense{}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mat.EqualApprox(sys.A, a, 1e-9) || !mat.EqualApprox(sys.C, mat.NewDense(1, 2, []float64{1, 0}), 1e-9) {
+			t.Error("Linearize returned wrong matrices without inputs:", sys.A, sys.C)
+		}
+		if !sys.B.IsEmpty() || !sys.D.IsEmpty() || !sys.U0.IsEmpty() {
+			t.Error("Linearize should return empty B, D and U0 without inputs")
+		}
+	}
+
+	sys, err := Linearize(f, none, x0, &mat.VecDense{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(sys.A, a, 1e-9) || !sys.C.IsEmpty() || !sys.D.IsEmpty() || !sys.Y0.IsEmpty() {
+		t.Error("Linearize should return empty C, D and Y0 without outputs")
+	}
+
+	// trim point of the pendulum without inputs
+	x, u, err := Trim(f, mat.NewVecDense(2, []float64{0.1, 0.1}), &mat.VecDense{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x.AtVec(0)) > 1e-8 || math.Abs(x.AtVec(1)) > 1e-8 || !u.IsEmpty() {
+		t.Error("Trim returned wrong trim point without inputs:", x, u)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	// tank x' = u - sqrt(x)
+	tank := func(x, u []float64) []float64 { return []float64{u[0] - math.Sqrt(x[0])} }
+	x, u, err := Trim(tank, mat.NewVecDense(1, []float64{1}), mat.NewVecDense(1, []float64{2}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x.AtVec(0)-4) > 1e-8 || u.AtVec(0) != 2 {
+		t.Error("Trim returned wrong trim point:", x.AtVec(0), u.AtVec(0))
+	}
+
+	// pendulum at the fixed angle 0.3 with the free velocity and input
+	f, _, _, _ := pendulum()
+	x, u, err = Trim(f, mat.NewVecDense(2, []float64{0.3, 1}), mat.NewVecDense(1, []float64{0}),
+		&TrimOptions{FreeStates: []int{1}, FreeInputs: []int{0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.AtVec(0) != 0.3 || math.Abs(x.AtVec(1)) > 1e-8 || math.Abs(u.AtVec(0)-math.Sin(0.3)) > 1e-8 {
+		t.Error("Trim returned wrong trim point:", x, u)
+	}
+
+	long := func(x, u []float64) []float64 {
+		if u[0] != 2 {
+			return []float64{0, 0}
+		}
+		return tank(x, u)
+	}
+	if _, _, err := Trim(long, mat.NewVecDense(1, []float64{1}), mat.NewVecDense(1, []float64{2}),
+		&TrimOptions{FreeInputs: []int{0}}); err == nil {
+		t.Error("expected error for wrong number of values")
+	}
+}
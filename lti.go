@@ -28,3 +28,19 @@ type Predictor interface {
 	LTI
 	Predict(x, u *mat.VecDense) *mat.VecDense
 }
+
+//CovariancePredictor represents a covariance propagation of a discretized LTI system
+type CovariancePredictor interface {
+	PredictSym(p mat.Symmetric) *mat.SymDense
+	PredictTo(dst *mat.SymDense, p mat.Symmetric)
+}
+
+//FrequencyResponder represents a time-continuous (System) or
+//time-discrete (Discrete) LTI system in the frequency domain.
+//The margin, Nyquist and PID tuning tools evaluate the state-space model
+//of System and Discrete and return an error for other implementations.
+type FrequencyResponder interface {
+	FrequencyResponse(omegas []float64) ([]*mat.CDense, error)
+	FrequencyGrid(n int) ([]float64, error)
+	Poles() ([]complex128, error)
+}
@@ -0,0 +1,150 @@
+package lti
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrUnstable is returned when an operation requires an asymptotically stable system
+var ErrUnstable = errors.New("system is not asymptotically stable")
+
+// maxDoublings limits the iterations of the Smith doubling algorithm
+const maxDoublings = 100
+
+//Lyapunov solves the continuous Lyapunov equation A * X + X * A^T + Q = 0
+//for a stable matrix A and returns the symmetric solution X.
+func Lyapunov(a *mat.Dense, q mat.Symmetric) (*mat.SymDense, error) {
+	n, err := checkLyapunovDims(a, q)
+	if err != nil {
+		return nil, err
+	}
+
+	ev, err := eigenvalues(a)
+	if err != nil {
+		return nil, err
+	}
+	if !stableContinuous(ev) {
+		return nil, ErrUnstable
+	}
+
+	// Cayley transformation with shift p into the discrete equation
+	// X = Ad * X * Ad^T + Qd with
+	// Ad = (A + pI) (A - pI)^-1 and Qd = 2p (A - pI)^-1 Q (A - pI)^-T
+	p := cayleyShift(ev)
+
+	var apI, amI mat.Dense
+	apI.CloneFrom(a)
+	amI.CloneFrom(a)
+	for i := 0; i < n; i++ {
+		apI.Set(i, i, apI.At(i, i)+p)
+		amI.Set(i, i, amI.At(i, i)-p)
+	}
+	var inv mat.Dense
+	if err := inv.Inverse(&amI); err != nil {
+		return nil, errors.New("Lyapunov: Cayley transformation failed")
+	}
+
+	var ad mat.Dense
+	ad.Mul(&apI, &inv)
+
+	var iq, qd mat.Dense
+	iq.Mul(&inv, q)
+	qd.Mul(&iq, inv.T())
+	qd.Scale(2*p, &qd)
+
+	return smithDoubling(&ad, &qd)
+}
+
+//DiscreteLyapunov solves the discrete Lyapunov equation X = A * X * A^T + Q
+//for a stable matrix A and returns the symmetric solution X.
+func DiscreteLyapunov(a *mat.Dense, q mat.Symmetric) (*mat.SymDense, error) {
+	if _, err := checkLyapunovDims(a, q); err != nil {
+		return nil, err
+	}
+
+	ev, err := eigenvalues(a)
+	if err != nil {
+		return nil, err
+	}
+	if !stableDiscrete(ev) {
+		return nil, ErrUnstable
+	}
+
+	var qd mat.Dense
+	qd.CloneFrom(q)
+
+	return smithDoubling(a, &qd)
+}
+
+// smithDoubling sums X = Sum_k A^k Q A^kT with the doubling iteration
+// X(j+1) = X(j) + A(j) X(j) A(j)^T, A(j+1) = A(j)^2
+func smithDoubling(a, q *mat.Dense) (*mat.SymDense, error) {
+	var ak, x, ax, axat mat.Dense
+	ak.CloneFrom(a)
+	x.CloneFrom(q)
+
+	for i := 0; i < maxDoublings; i++ {
+		ax.Mul(&ak, &x)
+		axat.Mul(&ax, ak.T())
+		x.Add(&x, &axat)
+
+		if mat.Norm(&axat, 1) <= 1e-15*mat.Norm(&x, 1) {
+			var res mat.SymDense
+			symmetrize(&res, &x)
+			return &res, nil
+		}
+		ak.Mul(&ak, &ak)
+	}
+
+	return nil, errors.New("Lyapunov: iteration did not converge")
+}
+
+// checkLyapunovDims checks that a is square and matches q
+func checkLyapunovDims(a *mat.Dense, q mat.Symmetric) (int, error) {
+	r, c := a.Dims()
+	if r != c {
+		return 0, errors.New("A should be squared")
+	}
+	if q.Symmetric() != r {
+		return 0, errors.New("Q dim should be equal to A row dim")
+	}
+	return r, nil
+}
+
+// cayleyShift returns the geometric mean of the smallest and largest
+// eigenvalue magnitude as the shift of the Cayley transformation
+func cayleyShift(ev []complex128) float64 {
+	lo, hi := math.Inf(1), 0.0
+	for _, v := range ev {
+		abs := cmplx.Abs(v)
+		lo = math.Min(lo, abs)
+		hi = math.Max(hi, abs)
+	}
+	if hi == 0 {
+		return 1
+	}
+	return math.Sqrt(lo * hi)
+}
+
+// stableContinuous returns true if all eigenvalues have a negative real part
+func stableContinuous(ev []complex128) bool {
+	for _, v := range ev {
+		if real(v) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// stableDiscrete returns true if all eigenvalues lie inside the unit circle
+func stableDiscrete(ev []complex128) bool {
+	for _, v := range ev {
+		if cmplx.Abs(v) >= 1 {
+			return false
+		}
+	}
+	return true
+}
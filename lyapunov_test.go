@@ -0,0 +1,75 @@
+package lti
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLyapunov(t *testing.T) {
+	a := mat.NewDense(3, 3, []float64{
+		-1, 2, 0,
+		-2, -1, 0.5,
+		0, 0, -10,
+	})
+	q := mat.NewSymDense(3, []float64{
+		1, 0.2, 0,
+		0.2, 2, 0.1,
+		0, 0.1, 3,
+	})
+
+	x, err := Lyapunov(a, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// residual A X + X A^T + Q
+	var ax, res mat.Dense
+	ax.Mul(a, x)
+	res.Add(&ax, ax.T())
+	res.Add(&res, q)
+	if mat.Norm(&res, 1) > 1e-10 {
+		fmt.Println("residual:", mat.Formatted(&res))
+		t.Error("Lyapunov returned wrong solution")
+	}
+
+	// unstable
+	a.Set(2, 2, 1)
+	if _, err := Lyapunov(a, q); err != ErrUnstable {
+		t.Error("expected ErrUnstable, received", err)
+	}
+}
+
+func TestDiscreteLyapunov(t *testing.T) {
+	a := mat.NewDense(2, 2, []float64{
+		0.9, 0.1,
+		0, 0.99,
+	})
+	q := mat.NewSymDense(2, []float64{
+		1, 0,
+		0, 0.1,
+	})
+
+	x, err := DiscreteLyapunov(a, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// residual A X A^T + Q - X
+	var ax, res mat.Dense
+	ax.Mul(a, x)
+	res.Mul(&ax, a.T())
+	res.Add(&res, q)
+	res.Sub(&res, x)
+	if mat.Norm(&res, 1) > 1e-9 {
+		fmt.Println("residual:", mat.Formatted(&res))
+		t.Error("DiscreteLyapunov returned wrong solution")
+	}
+
+	// marginally stable
+	a.Set(1, 1, 1)
+	if _, err := DiscreteLyapunov(a, q); err != ErrUnstable {
+		t.Error("expected ErrUnstable, received", err)
+	}
+}
@@ -0,0 +1,359 @@
+package lti
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// marginGridSize is the number of frequencies used to bracket crossovers
+const marginGridSize = 2000
+
+//StabilityMargins contains the classical stability margins of a SISO open loop L.
+//
+// The fields are:
+// 	GainMargin:     Factor by which the loop gain can change until instability (+Inf if the phase never crosses -180 deg)
+// 	PhaseMargin:    Additional phase lag in degrees until instability (+Inf if |L| never crosses 1)
+// 	PhaseCrossover: Frequency (rad/s) where the phase of L is -180 deg
+// 	GainCrossover:  Frequency (rad/s) where |L| = 1
+// 	DelayMargin:    Additional time delay in seconds until instability
+// 	Stable:         The closed loop with unity negative feedback is stable
+//
+//The margins are only meaningful if the nominal closed loop is stable.
+type StabilityMargins struct {
+	GainMargin     float64
+	PhaseMargin    float64
+	PhaseCrossover float64
+	GainCrossover  float64
+	DelayMargin    float64
+	Stable         bool
+}
+
+//Margins returns the gain, phase and delay margins of the SISO open loop L.
+//Crossovers are bracketed on a frequency grid and then located by root-finding
+//on the frequency response. If there are several crossovers, the margins
+//closest to instability are returned.
+func Margins(loop FrequencyResponder) (*StabilityMargins, error) {
+	e, err := evaluatorOf(loop)
+	if err != nil {
+		return nil, err
+	}
+	if e.p != 1 || e.m != 1 {
+		return nil, errors.New("Margins: loop should be SISO")
+	}
+	grid, err := loop.FrequencyGrid(marginGridSize)
+	if err != nil {
+		return nil, err
+	}
+
+	l := func(w float64) complex128 {
+		v, err := e.at(e.point(w), 0, 0)
+		if err != nil {
+			return cmplx.Inf()
+		}
+		return v
+	}
+
+	stable, err := closedLoopStable(loop)
+	if err != nil {
+		return nil, err
+	}
+
+	margins := &StabilityMargins{
+		GainMargin:  math.Inf(1),
+		PhaseMargin: math.Inf(1),
+		DelayMargin: math.Inf(1),
+		Stable:      stable,
+	}
+
+	// gain crossovers |L| = 1
+	logMag := func(w float64) float64 { return math.Log(cmplx.Abs(l(w))) }
+	for _, wc := range crossings(logMag, grid) {
+		pm := wrapDegrees(180 + cmplx.Phase(l(wc))*180/math.Pi)
+		if math.Abs(pm) < math.Abs(margins.PhaseMargin) {
+			margins.PhaseMargin = pm
+			margins.GainCrossover = wc
+		}
+		dm := pm
+		if dm < 0 {
+			dm += 360
+		}
+		dm = dm * math.Pi / 180 / wc
+		if dm < margins.DelayMargin {
+			margins.DelayMargin = dm
+		}
+	}
+
+	freqs, gms := phaseCrossovers(l, grid, e.dt)
+	for i, gm := range gms {
+		if math.Abs(math.Log(gm)) < math.Abs(math.Log(margins.GainMargin)) {
+			margins.GainMargin = gm
+			margins.PhaseCrossover = freqs[i]
+		}
+	}
+
+	return margins, nil
+}
+
+// phaseCrossovers returns the frequencies where the phase of the SISO loop l
+// with the sample time dt is -180 deg and the gain margins 1 / |L| there
+func phaseCrossovers(l func(float64) complex128, grid []float64, dt float64) ([]float64, []float64) {
+	// phase crossovers Im(L) = 0 and Re(L) < 0
+	imagPart := func(w float64) float64 { return imag(l(w)) }
+	candidates := crossings(imagPart, grid)
+
+	// the frequency response is real at w = 0 and at the Nyquist frequency
+	candidates = append(candidates, 0)
+	if dt > 0 {
+		candidates = append(candidates, math.Pi/dt)
+	}
+	var freqs, gms []float64
+	for _, wp := range candidates {
+		v := l(wp)
+		if cmplx.IsInf(v) || real(v) >= 0 || math.Abs(imag(v)) > 1e-6*cmplx.Abs(v) {
+			continue
+		}
+		freqs = append(freqs, wp)
+		gms = append(gms, 1/cmplx.Abs(v))
+	}
+	return freqs, gms
+}
+
+//DiskMargin contains the symmetric disk margin of an open loop L.
+//The loop remains stable for simultaneous gain and phase variations
+//inside the disk, i.e. gains within [GainMargin[0], GainMargin[1]]
+//or phase variations up to +/- PhaseMargin degrees in all channels.
+//The disk margin only guarantees stability if the nominal closed loop
+//with unity negative feedback is stable, which is reported by Stable.
+type DiskMargin struct {
+	Alpha       float64
+	GainMargin  [2]float64
+	PhaseMargin float64
+	Frequency   float64
+	Stable      bool
+}
+
+//DiskMargins returns the symmetric disk margin alpha = 2 / max_w s(S - T)
+//of the square open loop L with the sensitivity S = (I + L)^-1 and the
+//complementary sensitivity T = L (I + L)^-1. For MIMO loops the maximum
+//singular value s is used, which gives a conservative bound for
+//simultaneous variations in all channels.
+func DiskMargins(loop FrequencyResponder) (*DiskMargin, error) {
+	e, err := evaluatorOf(loop)
+	if err != nil {
+		return nil, err
+	}
+	if e.p != e.m {
+		return nil, errors.New("DiskMargins: loop should be square")
+	}
+	grid, err := loop.FrequencyGrid(marginGridSize)
+	if err != nil {
+		return nil, err
+	}
+	stable, err := closedLoopStable(loop)
+	if err != nil {
+		return nil, err
+	}
+
+	n := e.m
+	g := mat.NewCDense(n, n, nil)
+	peak := func(w float64) float64 {
+		if err := e.evalTo(g, e.point(w)); err != nil {
+			// |L| is unbounded at a pole on the contour: S - T = -I
+			return 1
+		}
+		// S - T = (I - L) (I + L)^-1
+		var ipl, iml mat.Dense
+		ipl.Scale(1, realEmbedding(g))
+		iml.Scale(-1, &ipl)
+		for i := 0; i < 2*n; i++ {
+			ipl.Set(i, i, ipl.At(i, i)+1)
+			iml.Set(i, i, iml.At(i, i)+1)
+		}
+		var inv, smt mat.Dense
+		if err := inv.Inverse(&ipl); err != nil {
+			return math.Inf(1)
+		}
+		smt.Mul(&iml, &inv)
+		var svd mat.SVD
+		if ok := svd.Factorize(&smt, mat.SVDNone); !ok {
+			return math.NaN()
+		}
+		return svd.Values(nil)[0]
+	}
+
+	wmax, vmax := maximize(peak, grid)
+
+	alpha := 2 / vmax
+	dm := &DiskMargin{
+		Alpha:       alpha,
+		PhaseMargin: 2 * math.Atan(alpha/2) * 180 / math.Pi,
+		Frequency:   wmax,
+		Stable:      stable,
+	}
+	if alpha < 2 {
+		dm.GainMargin = [2]float64{(2 - alpha) / (2 + alpha), (2 + alpha) / (2 - alpha)}
+	} else {
+		dm.GainMargin = [2]float64{0, math.Inf(1)}
+	}
+	return dm, nil
+}
+
+// closedLoopStable returns true if the unity negative feedback loop of the
+// System or Discrete open loop L is stable. Without delays the closed loop
+// poles are the eigenvalues of A - B (I + D)^-1 C, delayed SISO loops are
+// checked with the Nyquist criterion.
+func closedLoopStable(loop FrequencyResponder) (bool, error) {
+	var a, b, c, d *mat.Dense
+	stable := stableContinuous
+	switch sys := loop.(type) {
+	case *System:
+		if sys.hasDelays() {
+			if p, m := sys.D.Dims(); p != 1 || m != 1 {
+				return false, ErrDelays
+			}
+			data, err := Nyquist(sys, marginGridSize)
+			if err != nil {
+				return false, err
+			}
+			return data.ClosedLoopUnstable == 0, nil
+		}
+		a, b, c, d = sys.A, sys.B, sys.C, sys.D
+	case *Discrete:
+		a, b, c, d = sys.Ad, sys.Bd, sys.C, sys.D
+		stable = stableDiscrete
+	default:
+		return false, errors.New("frequency analysis needs a System or Discrete")
+	}
+
+	n, _ := a.Dims()
+	p, _ := d.Dims()
+	var ipd, inv mat.Dense
+	ipd.Scale(1, d)
+	for i := 0; i < p; i++ {
+		ipd.Set(i, i, ipd.At(i, i)+1)
+	}
+	if err := inv.Inverse(&ipd); err != nil {
+		// the loop is not well-posed
+		return false, nil
+	}
+	if n == 0 {
+		return true, nil
+	}
+	var bic, acl mat.Dense
+	bic.Mul(b, &inv)
+	acl.Mul(&bic, c)
+	acl.Sub(a, &acl)
+	ev, err := eigenvalues(&acl)
+	if err != nil {
+		return false, err
+	}
+	return stable(ev), nil
+}
+
+// crossings returns the refined zero crossings of f between
+// consecutive grid points
+func crossings(f func(float64) float64, grid []float64) []float64 {
+	var roots []float64
+	prevW, prevF := grid[0], f(grid[0])
+	for _, w := range grid[1:] {
+		fw := f(w)
+		if isFinite(prevF) && isFinite(fw) {
+			if fw == 0 {
+				roots = append(roots, w)
+			} else if prevF*fw < 0 {
+				roots = append(roots, findRoot(f, prevW, w, prevF, fw))
+			}
+		}
+		prevW, prevF = w, fw
+	}
+	return roots
+}
+
+// findRoot locates a root of f inside [a, b] with f(a) f(b) < 0 using the
+// Illinois variant of the regula falsi in logarithmic frequency
+func findRoot(f func(float64) float64, a, b, fa, fb float64) float64 {
+	if a <= 0 {
+		a = b * 1e-12
+		fa = f(a)
+	}
+	la, lb := math.Log(a), math.Log(b)
+	for i := 0; i < 100; i++ {
+		lc := (la*fb - lb*fa) / (fb - fa)
+		if math.IsNaN(lc) {
+			lc = 0.5 * (la + lb)
+		}
+		fc := f(math.Exp(lc))
+		if fc == 0 || math.Abs(lb-la) < 1e-14*math.Max(1, math.Abs(lc)) {
+			return math.Exp(lc)
+		}
+		if fc*fb < 0 {
+			la, fa = lb, fb
+		} else {
+			fa /= 2
+		}
+		lb, fb = lc, fc
+	}
+	return math.Exp(0.5 * (la + lb))
+}
+
+// maximize returns the location and value of the maximum of f,
+// refined by a golden section search around the largest grid point
+func maximize(f func(float64) float64, grid []float64) (float64, float64) {
+	imax, vmax := 0, math.Inf(-1)
+	for i, w := range grid {
+		if v := f(w); v > vmax {
+			imax, vmax = i, v
+		}
+	}
+	if math.IsInf(vmax, 1) {
+		return grid[imax], vmax
+	}
+
+	lo, hi := grid[imax], grid[imax]
+	if imax > 0 {
+		lo = grid[imax-1]
+	}
+	if imax < len(grid)-1 {
+		hi = grid[imax+1]
+	}
+	a, b := math.Log(lo), math.Log(hi)
+	ratio := (math.Sqrt(5) - 1) / 2
+	c := b - ratio*(b-a)
+	d := a + ratio*(b-a)
+	fc, fd := f(math.Exp(c)), f(math.Exp(d))
+	for i := 0; i < 100 && b-a > 1e-10; i++ {
+		if fc > fd {
+			b, d, fd = d, c, fc
+			c = b - ratio*(b-a)
+			fc = f(math.Exp(c))
+		} else {
+			a, c, fc = c, d, fd
+			d = a + ratio*(b-a)
+			fd = f(math.Exp(d))
+		}
+	}
+	wmax := math.Exp(0.5 * (a + b))
+	if v := f(wmax); v > vmax {
+		return wmax, v
+	}
+	return grid[imax], vmax
+}
+
+// wrapDegrees wraps an angle in degrees into (-180, 180]
+func wrapDegrees(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg > 180 {
+		deg -= 360
+	} else if deg <= -180 {
+		deg += 360
+	}
+	return deg
+}
+
+// isFinite returns true if x is neither NaN nor infinite
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
@@ -0,0 +1,200 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// newTestLoop returns L(s) = k / (s (s + 1) (s + 2))
+func newTestLoop(k float64) *System {
+	sys, _ := NewSystem(
+		mat.NewDense(3, 3, []float64{
+			0, 1, 0,
+			0, 0, 1,
+			0, -2, -3,
+		}),
+		mat.NewDense(3, 1, []float64{0, 0, 1}),
+		mat.NewDense(1, 3, []float64{k, 0, 0}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	return sys
+}
+
+func TestMargins(t *testing.T) {
+	loop := newTestLoop(2)
+
+	m, err := Margins(loop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// phase crossover at w = sqrt(2) with |L| = k / 6
+	if math.Abs(m.GainMargin-3) > 1e-8 || math.Abs(m.PhaseCrossover-math.Sqrt2) > 1e-8 {
+		fmt.Printf("%+v\n", m)
+		t.Error("wrong gain margin")
+	}
+
+	// the phase margin is consistent with the gain crossover
+	h, _ := loop.FrequencyResponse([]float64{m.GainCrossover})
+	l := h[0].At(0, 0)
+	if math.Abs(cmplx.Abs(l)-1) > 1e-8 {
+		t.Error("|L| at gain crossover is", cmplx.Abs(l))
+	}
+	pm := 180 + cmplx.Phase(l)*180/math.Pi
+	if math.Abs(m.PhaseMargin-pm) > 1e-8 || m.PhaseMargin < 30 || m.PhaseMargin > 35 {
+		fmt.Printf("%+v\n", m)
+		t.Error("wrong phase margin")
+	}
+	if math.Abs(m.DelayMargin-m.PhaseMargin*math.Pi/180/m.GainCrossover) > 1e-12 {
+		t.Error("wrong delay margin")
+	}
+
+	// unstable loop has a gain margin below one and a negative phase margin
+	m, err = Margins(newTestLoop(12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.GainMargin-0.5) > 1e-8 || m.PhaseMargin >= 0 {
+		fmt.Printf("%+v\n", m)
+		t.Error("wrong margins for unstable loop")
+	}
+}
+
+func TestMarginsDiscrete(t *testing.T) {
+	disc, err := newTestLoop(2).Discretize(0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Margins(disc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sampling adds phase lag and slightly reduces the margins
+	if m.GainMargin > 3 || m.GainMargin < 2.8 || m.PhaseMargin < 30 || m.PhaseMargin > 35 {
+		fmt.Printf("%+v\n", m)
+		t.Error("wrong margins for discrete loop")
+	}
+}
+
+func TestDiskMargins(t *testing.T) {
+	// L(s) = 1 / s has the disk margin alpha = 2 at every frequency
+	sys, _ := NewSystem(
+		mat.NewDense(1, 1, []float64{0}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	dm, err := DiskMargins(sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(dm.Alpha-2) > 1e-6 || math.Abs(dm.PhaseMargin-90) > 1e-4 {
+		fmt.Printf("%+v\n", dm)
+		t.Error("wrong disk margin for integrator")
+	}
+
+	// MIMO: two decoupled loops k / (s (s + 1)), the weaker one determines the margin
+	loop, _ := NewSystem(
+		mat.NewDense(4, 4, []float64{
+			0, 1, 0, 0,
+			0, -1, 0, 0,
+			0, 0, 0, 1,
+			0, 0, 0, -1,
+		}),
+		mat.NewDense(4, 2, []float64{
+			0, 0,
+			1, 0,
+			0, 0,
+			0, 1,
+		}),
+		mat.NewDense(2, 4, []float64{
+			0.5, 0, 0, 0,
+			0, 0, 2, 0,
+		}),
+		mat.NewDense(2, 2, []float64{0, 0, 0, 0}),
+	)
+	dm, err = DiskMargins(loop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weak, _ := NewSystem(
+		mat.NewDense(2, 2, []float64{0, 1, 0, -1}),
+		mat.NewDense(2, 1, []float64{0, 1}),
+		mat.NewDense(1, 2, []float64{2, 0}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	expected, err := DiskMargins(weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// |S - T| peaks at w = sqrt(k) with the value sqrt(4k + 1) for k / (s^2 + s)
+	if math.Abs(dm.Alpha-expected.Alpha) > 1e-8 || math.Abs(dm.Alpha-2.0/3) > 1e-6 || dm.GainMargin[0] >= 1 || dm.GainMargin[1] <= 1 {
+		fmt.Printf("%+v\n%+v\n", dm, expected)
+		t.Error("wrong MIMO disk margin")
+	}
+}
+
+func TestMarginsStable(t *testing.T) {
+	// the closed loop of k / (s (s + 1) (s + 2)) is stable for k < 6
+	for _, tc := range []struct {
+		k      float64
+		stable bool
+	}{{2, true}, {12, false}} {
+		loop := newTestLoop(tc.k)
+		m, err := Margins(loop)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dm, err := DiskMargins(loop)
+		if err != nil {
+			t.Fatal(err)
+		}
+		disc, err := loop.Discretize(0.01)
+		if err != nil {
+			t.Fatal(err)
+		}
+		md, err := Margins(disc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Stable != tc.stable || dm.Stable != tc.stable || md.Stable != tc.stable {
+			t.Errorf("k = %v: expected stable = %v, received %v, %v and %v", tc.k, tc.stable, m.Stable, dm.Stable, md.Stable)
+		}
+	}
+
+	// delayed loops k e^-s / (s + 1) are checked with the Nyquist criterion
+	for _, tc := range []struct {
+		k      float64
+		stable bool
+	}{{0.5, true}, {3, false}} {
+		loop, _ := NewSystem(
+			mat.NewDense(1, 1, []float64{-1}),
+			mat.NewDense(1, 1, []float64{1}),
+			mat.NewDense(1, 1, []float64{tc.k}),
+			mat.NewDense(1, 1, []float64{0}),
+		)
+		loop.InputDelay = []float64{1}
+		m, err := Margins(loop)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Stable != tc.stable {
+			t.Errorf("k = %v: expected stable = %v for delayed loop", tc.k, tc.stable)
+		}
+	}
+	mimo, _ := NewSystem(
+		mat.NewDense(1, 1, []float64{-1}),
+		mat.NewDense(1, 2, []float64{1, 1}),
+		mat.NewDense(2, 1, []float64{1, 1}),
+		mat.NewDense(2, 2, nil),
+	)
+	mimo.InputDelay = []float64{1, 0}
+	if _, err := DiskMargins(mimo); err != ErrDelays {
+		t.Error("expected ErrDelays for delayed MIMO loop, received:", err)
+	}
+}
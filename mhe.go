@@ -0,0 +1,355 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//MHE is a moving horizon estimator of a discrete plant
+// 	x(k+1) = A_d x(k) + B_d u(k) + G w(k),  y(k) = C x(k) + D u(k) + v(k)
+//with the process noise covariance Q and the measurement noise covariance R.
+//In every step the states of the window of the last N samples are estimated by
+//minimizing
+// 	(x0 - xbar)^T P^-1 (x0 - xbar) + Sum_k w(k)^T Q^-1 w(k) + v(k)^T R^-1 v(k)
+//subject to the state bounds. Samples leaving the window are absorbed into the
+//arrival cost xbar, P by a Kalman filter step with the Covariance prediction,
+//so that the unconstrained estimate equals the Kalman filter.
+//Missing measurements are NaN entries of y.
+type MHE struct {
+	Plant    *Discrete
+	Horizon  int
+	G        *mat.Dense // Noise input matrix (identity if nil)
+	Q        mat.Symmetric
+	R        mat.Symmetric
+	StateMin []float64 // nil if not bounded
+	StateMax []float64 // nil if not bounded
+	Arrival  *Covariance
+	XBar     *mat.VecDense // Prior of the oldest state in the window
+	P        *mat.SymDense // Covariance of the prior
+
+	qinv       *mat.SymDense
+	solvers    map[int]*QPSolver // Solvers by the length of the window
+	inputs     []*mat.VecDense
+	outputs    []*mat.VecDense
+	estimates  []*mat.VecDense
+	iterations int
+}
+
+//NewMHE returns a moving horizon estimator of the plant with the window of
+//N samples, the noise input matrix G (identity if nil), the noise covariances
+//Q and R and the initial estimate x0 with the covariance p0.
+func NewMHE(plant *Discrete, horizon int, g *mat.Dense, q, r mat.Symmetric, x0 *mat.VecDense, p0 mat.Symmetric) (*MHE, error) {
+	if horizon < 1 {
+		return nil, errors.New("MHE: horizon should be positive")
+	}
+	n, _ := plant.Ad.Dims()
+	p, _ := plant.C.Dims()
+	if r == nil || r.Symmetric() != p {
+		return nil, errors.New("MHE: R should match the number of outputs")
+	}
+	if x0.Len() != n || p0.Symmetric() != n {
+		return nil, errors.New("MHE: initial estimate should match the number of states")
+	}
+	arrival, err := NewCovarianceWithNoise(plant.Ad, g, q)
+	if err != nil {
+		return nil, err
+	}
+	qinv, err := inverseSym(q)
+	if err != nil {
+		return nil, errors.New("MHE: Q should be positive definite")
+	}
+	e := &MHE{
+		Plant:   plant,
+		Horizon: horizon,
+		G:       g,
+		Q:       q,
+		R:       r,
+		Arrival: arrival,
+		XBar:    mat.VecDenseCopyOf(x0),
+		P:       mat.NewSymDense(n, nil),
+		qinv:    qinv,
+		solvers: make(map[int]*QPSolver),
+	}
+	e.P.CopySym(p0)
+	return e, nil
+}
+
+//Update adds the input u(k) and the measurement y(k) to the window and returns
+//the estimate of the current state x(k). Missing measurements are NaN entries
+//of y, or y is nil if no measurement is available. If the estimation fails,
+//the window and the arrival cost are left unchanged.
+func (e *MHE) Update(u, y *mat.VecDense) (*mat.VecDense, error) {
+	_, m := e.Plant.Bd.Dims()
+	p, _ := e.Plant.C.Dims()
+	if u.Len() != m {
+		return nil, errors.New("MHE: u should match the number of inputs")
+	}
+	if y == nil {
+		y = mat.NewVecDense(p, nil)
+		for i := 0; i < p; i++ {
+			y.SetVec(i, math.NaN())
+		}
+	}
+	if y.Len() != p {
+		return nil, errors.New("MHE: y should match the number of outputs")
+	}
+	inputs := append(append([]*mat.VecDense(nil), e.inputs...), mat.VecDenseCopyOf(u))
+	outputs := append(append([]*mat.VecDense(nil), e.outputs...), mat.VecDenseCopyOf(y))
+
+	// absorb the oldest sample into the arrival cost
+	xbar, pbar := e.XBar, e.P
+	if len(inputs) > e.Horizon {
+		var err error
+		xbar, pbar, err = e.updateArrival(inputs[0], outputs[0])
+		if err != nil {
+			return nil, err
+		}
+		inputs = inputs[1:]
+		outputs = outputs[1:]
+	}
+
+	estimates, err := e.solve(inputs, outputs, xbar, pbar)
+	if err != nil {
+		return nil, err
+	}
+	e.inputs, e.outputs = inputs, outputs
+	e.XBar, e.P = xbar, pbar
+	e.estimates = estimates
+	return e.State(), nil
+}
+
+//State returns a copy of the estimate of the current state.
+func (e *MHE) State() *mat.VecDense {
+	if len(e.estimates) == 0 {
+		return mat.VecDenseCopyOf(e.XBar)
+	}
+	return mat.VecDenseCopyOf(e.estimates[len(e.estimates)-1])
+}
+
+//Trajectory returns copies of the estimated states of the window,
+//from the oldest to the current state.
+func (e *MHE) Trajectory() []*mat.VecDense {
+	res := make([]*mat.VecDense, len(e.estimates))
+	for i, x := range e.estimates {
+		res[i] = mat.VecDenseCopyOf(x)
+	}
+	return res
+}
+
+//Iterations returns the number of QP iterations of the last update.
+func (e *MHE) Iterations() int {
+	return e.iterations
+}
+
+// updateArrival returns the prior xbar, P propagated by a Kalman filter step
+// with the measurement y and the input u
+func (e *MHE) updateArrival(u, y *mat.VecDense) (*mat.VecDense, *mat.SymDense, error) {
+	n, _ := e.Plant.Ad.Dims()
+	ca, ra, resid := e.measurement(u, y, e.XBar)
+
+	// measurement update x = xbar + K (y - C xbar - D u), P = (I - K C) P
+	x := mat.VecDenseCopyOf(e.XBar)
+	pu := mat.NewSymDense(n, nil)
+	pu.CopySym(e.P)
+	if ca != nil {
+		var pct, s, k mat.Dense
+		pct.Mul(e.P, ca.T())
+		s.Mul(ca, &pct)
+		s.Add(&s, ra)
+		var sinv mat.Dense
+		if err := sinv.Inverse(&s); err != nil {
+			return nil, nil, errors.New("MHE: innovation covariance is singular")
+		}
+		k.Mul(&pct, &sinv)
+		var kr mat.VecDense
+		kr.MulVec(&k, resid)
+		x.AddVec(x, &kr)
+		var kcp, pnew mat.Dense
+		kcp.Mul(&k, pct.T())
+		pnew.Sub(e.P, &kcp)
+		symmetrize(pu, &pnew)
+	}
+
+	// time update xbar = A_d x + B_d u, P = A_d P A_d^T + G Q G^T
+	xbar := mat.VecDenseCopyOf(e.Plant.Predict(x, u))
+	p := mat.NewSymDense(n, nil)
+	e.Arrival.PredictTo(p, pu)
+	return xbar, p, nil
+}
+
+// measurement returns the rows of C, the covariance R and the residual
+// y - C x - D u of the available measurements, or nil if y is missing
+func (e *MHE) measurement(u, y, x *mat.VecDense) (*mat.Dense, *mat.Dense, *mat.VecDense) {
+	p, n := e.Plant.C.Dims()
+	_, m := e.Plant.D.Dims()
+	var idx []int
+	for i := 0; i < p; i++ {
+		if !math.IsNaN(y.AtVec(i)) {
+			idx = append(idx, i)
+		}
+	}
+	if len(idx) == 0 {
+		return nil, nil, nil
+	}
+	ca := mat.NewDense(len(idx), n, nil)
+	da := mat.NewDense(len(idx), m, nil)
+	ra := mat.NewDense(len(idx), len(idx), nil)
+	resid := mat.NewVecDense(len(idx), nil)
+	for a, i := range idx {
+		ca.SetRow(a, mat.Row(nil, i, e.Plant.C))
+		da.SetRow(a, mat.Row(nil, i, e.Plant.D))
+		for b, j := range idx {
+			ra.Set(a, b, e.R.At(i, j))
+		}
+		resid.SetVec(a, y.AtVec(i))
+	}
+	var cx, du mat.VecDense
+	cx.MulVec(ca, x)
+	resid.SubVec(resid, &cx)
+	du.MulVec(da, u)
+	resid.SubVec(resid, &du)
+	return ca, ra, resid
+}
+
+// solve returns the estimated states of the window with the prior xbar, P by
+// the condensed QP in z = [x0; w(0); ...; w(L-2)] with x(i) = Phi(i) z + o(i)
+func (e *MHE) solve(inputs, outputs []*mat.VecDense, xbar *mat.VecDense, pbar *mat.SymDense) ([]*mat.VecDense, error) {
+	n, _ := e.Plant.Ad.Dims()
+	nw := n
+	if e.G != nil {
+		_, nw = e.G.Dims()
+	}
+	l := len(inputs)
+	nz := n + (l-1)*nw
+
+	pinv, err := inverseSym(pbar)
+	if err != nil {
+		return nil, errors.New("MHE: arrival covariance should be positive definite")
+	}
+
+	// arrival cost and process noise
+	h := mat.NewDense(nz, nz, nil)
+	f := mat.NewVecDense(nz, nil)
+	setBlock(h, 0, 0, mat.DenseCopyOf(pinv))
+	var pxbar mat.VecDense
+	pxbar.MulVec(pinv, xbar)
+	for i := 0; i < n; i++ {
+		f.SetVec(i, -pxbar.AtVec(i))
+	}
+	for k := 0; k < l-1; k++ {
+		setBlock(h, n+k*nw, n+k*nw, mat.DenseCopyOf(e.qinv))
+	}
+
+	// measurements and state bounds along the window
+	phi := mat.NewDense(n, nz, nil)
+	setIdentity(phi, 0, 0, n)
+	o := mat.NewVecDense(n, nil)
+	phis := make([]*mat.Dense, l)
+	offsets := make([]*mat.VecDense, l)
+	for i := 0; i < l; i++ {
+		phis[i], offsets[i] = mat.DenseCopyOf(phi), mat.VecDenseCopyOf(o)
+
+		// (C Phi z + C o + D u - y)^T R^-1 (...)
+		if ca, ra, resid := e.measurement(inputs[i], outputs[i], o); ca != nil {
+			var rinv, cphi, rc, hc mat.Dense
+			if err := rinv.Inverse(ra); err != nil {
+				return nil, errors.New("MHE: R should be positive definite")
+			}
+			cphi.Mul(ca, phi)
+			rc.Mul(&rinv, &cphi)
+			hc.Mul(cphi.T(), &rc)
+			h.Add(h, &hc)
+			var fr mat.VecDense
+			fr.MulVec(rc.T(), resid)
+			f.SubVec(f, &fr)
+		}
+
+		if i < l-1 {
+			// Phi(i+1) = A_d Phi(i) + G E_w(i), o(i+1) = A_d o(i) + B_d u(i)
+			var next mat.Dense
+			next.Mul(e.Plant.Ad, phi)
+			if e.G != nil {
+				setBlock(&next, 0, n+i*nw, e.G)
+			} else {
+				setIdentity(&next, 0, n+i*nw, n)
+			}
+			phi = &next
+			o = mat.VecDenseCopyOf(e.Plant.Predict(o, inputs[i]))
+		}
+	}
+
+	hs := mat.NewSymDense(nz, nil)
+	for i := 0; i < nz; i++ {
+		for j := i; j < nz; j++ {
+			hs.SetSym(i, j, 0.5*(h.At(i, j)+h.At(j, i)))
+		}
+	}
+
+	// state bounds StateMin - o(i) <= Phi(i) z <= StateMax - o(i)
+	var a *mat.Dense
+	var bounds QPBounds
+	if e.StateMin != nil || e.StateMax != nil {
+		if (e.StateMin != nil && len(e.StateMin) != n) || (e.StateMax != nil && len(e.StateMax) != n) {
+			return nil, errors.New("MHE: state bounds should match the number of states")
+		}
+		a = mat.NewDense(l*n, nz, nil)
+		bounds.Lower = make([]float64, l*n)
+		bounds.Upper = make([]float64, l*n)
+		for i := 0; i < l; i++ {
+			setBlock(a, i*n, 0, phis[i])
+			for k := 0; k < n; k++ {
+				lo, hi := math.Inf(-1), math.Inf(1)
+				if e.StateMin != nil {
+					lo = e.StateMin[k] - offsets[i].AtVec(k)
+				}
+				if e.StateMax != nil {
+					hi = e.StateMax[k] - offsets[i].AtVec(k)
+				}
+				bounds.Lower[i*n+k], bounds.Upper[i*n+k] = lo, hi
+			}
+		}
+	}
+
+	// the solver of the window length is reused to start from the last
+	// active set
+	qp := e.solvers[l]
+	if qp != nil && (qp.A == nil) == (a == nil) {
+		err = qp.setProblem(hs, a)
+	} else {
+		qp, err = NewQPSolver(hs, a, QPOptions{Method: ActiveSet})
+	}
+	if err != nil {
+		delete(e.solvers, l)
+		return nil, err
+	}
+	z, err := qp.Solve(f, bounds)
+	e.iterations = qp.Iterations()
+	if err != nil {
+		return nil, err
+	}
+	e.solvers[l] = qp
+
+	estimates := make([]*mat.VecDense, l)
+	for i := 0; i < l; i++ {
+		x := mat.NewVecDense(n, nil)
+		x.MulVec(phis[i], z)
+		x.AddVec(x, offsets[i])
+		estimates[i] = x
+	}
+	return estimates, nil
+}
+
+// inverseSym returns the inverse of the positive definite matrix s
+func inverseSym(s mat.Symmetric) (*mat.SymDense, error) {
+	var chol mat.Cholesky
+	if ok := chol.Factorize(s); !ok {
+		return nil, errors.New("matrix is not positive definite")
+	}
+	var inv mat.SymDense
+	if err := chol.InverseTo(&inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
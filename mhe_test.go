@@ -0,0 +1,226 @@
+package lti
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestMHEKalman(t *testing.T) {
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := mat.NewSymDense(2, []float64{0.01, 0, 0, 0.01})
+	r := mat.NewSymDense(1, []float64{0.1})
+	x0 := mat.NewVecDense(2, nil)
+	p0 := mat.NewSymDense(2, []float64{1, 0, 0, 1})
+	mhe, err := NewMHE(plant, 5, nil, q, r, x0, p0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Kalman filter for comparison
+	xbar := mat.VecDenseCopyOf(x0)
+	p := mat.DenseCopyOf(p0)
+
+	x := mat.NewVecDense(2, []float64{1, 0})
+	for k := 0; k < 30; k++ {
+		u := mat.NewVecDense(1, []float64{math.Sin(0.3 * float64(k))})
+		var y *mat.VecDense
+		if k%4 != 3 {
+			y = mat.NewVecDense(1, []float64{x.AtVec(0) + 0.3*math.Sin(1.7*float64(k))})
+		}
+
+		est, err := mhe.Update(u, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// measurement and time update of the Kalman filter
+		kf := mat.VecDenseCopyOf(xbar)
+		if y != nil {
+			var pct mat.Dense
+			pct.Mul(p, plant.C.T())
+			s := mat.Dot(mat.NewVecDense(2, mat.Row(nil, 0, plant.C)), pct.ColView(0)) + 0.1
+			gain := mat.NewVecDense(2, nil)
+			gain.ScaleVec(1/s, pct.ColView(0))
+			kf.AddScaledVec(kf, y.AtVec(0)-plant.Response(xbar, u).AtVec(0), gain)
+			var kcp mat.Dense
+			kcp.Outer(s, gain, gain)
+			p.Sub(p, &kcp)
+		}
+		if !mat.EqualApprox(est, kf, 1e-8) {
+			t.Fatal("MHE should equal the Kalman filter at step", k, ":", est.RawVector().Data, kf.RawVector().Data)
+		}
+		xbar = mat.VecDenseCopyOf(plant.Predict(kf, u))
+		var ap, apa mat.Dense
+		ap.Mul(plant.Ad, p)
+		apa.Mul(&ap, plant.Ad.T())
+		apa.Add(&apa, q)
+		p = mat.DenseCopyOf(&apa)
+
+		x = mat.VecDenseCopyOf(plant.Predict(x, u))
+	}
+	if len(mhe.Trajectory()) != 5 {
+		t.Error("MHE window should contain 5 states, received:", len(mhe.Trajectory()))
+	}
+}
+
+func TestMHEStateBounds(t *testing.T) {
+	// concentration x(k+1) = 0.9 x(k) at zero with negative measurement noise
+	plant := &Discrete{
+		Ad: mat.NewDense(1, 1, []float64{0.9}),
+		Bd: mat.NewDense(1, 1, []float64{1}),
+		C:  mat.NewDense(1, 1, []float64{1}),
+		D:  mat.NewDense(1, 1, []float64{0}),
+		Dt: 1,
+	}
+	q := mat.NewSymDense(1, []float64{0.01})
+	r := mat.NewSymDense(1, []float64{0.04})
+	x0 := mat.NewVecDense(1, []float64{0.1})
+	p0 := mat.NewSymDense(1, []float64{0.1})
+
+	bounded, err := NewMHE(plant, 8, nil, q, r, x0, p0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounded.StateMin = []float64{0}
+	free, err := NewMHE(plant, 8, nil, q, r, x0, p0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	negative := false
+	u := mat.NewVecDense(1, nil)
+	for k := 0; k < 40; k++ {
+		y := mat.NewVecDense(1, []float64{-0.2 * math.Abs(math.Sin(0.9*float64(k)))})
+		xb, err := bounded.Update(u, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xf, err := free.Update(u, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range bounded.Trajectory() {
+			if x.AtVec(0) < -1e-9 {
+				t.Fatal("MHE violates the state bound at step", k, ":", x.AtVec(0))
+			}
+		}
+		if xb.AtVec(0) < -1e-9 {
+			t.Fatal("MHE estimate violates the state bound at step", k, ":", xb.AtVec(0))
+		}
+		if xf.AtVec(0) < 0 {
+			negative = true
+		}
+	}
+	if !negative {
+		t.Error("unconstrained estimate should become negative")
+	}
+}
+
+func TestMHEErrors(t *testing.T) {
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := mat.NewSymDense(2, []float64{1, 0, 0, 1})
+	r := mat.NewSymDense(1, []float64{1})
+	x0 := mat.NewVecDense(2, nil)
+	if _, err := NewMHE(plant, 0, nil, q, r, x0, q); err == nil {
+		t.Error("expected error for zero horizon")
+	}
+	if _, err := NewMHE(plant, 5, nil, q, q, x0, q); err == nil {
+		t.Error("expected error for wrong size of R")
+	}
+	if _, err := NewMHE(plant, 5, nil, r, r, x0, q); err == nil {
+		t.Error("expected error for wrong size of Q")
+	}
+	mhe, err := NewMHE(plant, 5, nil, q, r, x0, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mhe.Update(mat.NewVecDense(2, nil), nil); err == nil {
+		t.Error("expected error for wrong size of u")
+	}
+	if _, err := mhe.Update(mat.NewVecDense(1, nil), mat.NewVecDense(2, nil)); err == nil {
+		t.Error("expected error for wrong size of y")
+	}
+
+	// without a measurement the estimate is the prior
+	x, err := mhe.Update(mat.NewVecDense(1, nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(x, x0, 1e-12) {
+		t.Error("MHE without measurement should return the prior, received:", x.RawVector().Data)
+	}
+}
+
+func TestMHEFailedUpdate(t *testing.T) {
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := mat.NewSymDense(2, []float64{0.01, 0, 0, 0.01})
+	r := mat.NewSymDense(1, []float64{0.1})
+	x0 := mat.NewVecDense(2, nil)
+	p0 := mat.NewSymDense(2, []float64{1, 0, 0, 1})
+	mhe, err := NewMHE(plant, 3, nil, q, r, x0, p0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference, err := NewMHE(plant, 3, nil, q, r, x0, p0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := func(e *MHE, k int) (*mat.VecDense, error) {
+		u := mat.NewVecDense(1, []float64{math.Sin(0.3 * float64(k))})
+		y := mat.NewVecDense(1, []float64{math.Cos(0.5 * float64(k))})
+		return e.Update(u, y)
+	}
+	var solver *QPSolver
+	for k := 0; k < 10; k++ {
+		if k == 5 {
+			// infeasible state bounds leave the estimator unchanged
+			xbar, trajectory := mat.VecDenseCopyOf(mhe.XBar), mhe.Trajectory()
+			p := mat.NewSymDense(2, nil)
+			p.CopySym(mhe.P)
+			mhe.StateMin, mhe.StateMax = []float64{1, 1}, []float64{-1, -1}
+			if _, err := step(mhe, k); err == nil {
+				t.Fatal("expected error for infeasible state bounds")
+			}
+			mhe.StateMin, mhe.StateMax = nil, nil
+			if !mat.Equal(mhe.XBar, xbar) || !mat.Equal(mhe.P, p) || len(mhe.Trajectory()) != len(trajectory) {
+				t.Fatal("failed update should not change the estimator")
+			}
+			for i, x := range mhe.Trajectory() {
+				if !mat.Equal(x, trajectory[i]) {
+					t.Fatal("failed update should not change the window")
+				}
+			}
+		}
+		x, err := step(mhe, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xr, err := step(reference, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mat.EqualApprox(x, xr, 1e-10) {
+			t.Fatal("MHE differs from the reference at step", k, ":", x.RawVector().Data, xr.RawVector().Data)
+		}
+
+		// the solver of the full window is reused
+		if k >= 3 {
+			if solver != nil && mhe.solvers[3] != solver {
+				t.Fatal("MHE should reuse the solver of the window at step", k)
+			}
+			solver = mhe.solvers[3]
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package lti
+
+import (
+	"errors"
+	"math/cmplx"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//ModeSelector decides which eigenmodes of a system are kept. It receives
+//one pole in the s-plane for every mode, i.e. every real pole and the pole
+//with the positive imaginary part of every complex conjugate pair, and
+//returns true for every mode to keep. A kept pair adds two states.
+type ModeSelector func(poles []complex128) []bool
+
+//PolesInRegion returns a ModeSelector keeping the modes with the pole inside the region,
+//e.g. func(p complex128) bool { return real(p) > -10 } for the poles slower than 10 rad/s.
+func PolesInRegion(inside func(pole complex128) bool) ModeSelector {
+	return func(poles []complex128) []bool {
+		keep := make([]bool, len(poles))
+		for i, p := range poles {
+			keep[i] = inside(p)
+		}
+		return keep
+	}
+}
+
+//SlowestModes returns a ModeSelector keeping the k modes with the smallest
+//pole magnitude. A complex conjugate pair counts as one mode, so that up
+//to 2k states are kept.
+func SlowestModes(k int) ModeSelector {
+	return func(poles []complex128) []bool {
+		idx := sequence(len(poles))
+		sort.SliceStable(idx, func(a, b int) bool {
+			return cmplx.Abs(poles[idx[a]]) < cmplx.Abs(poles[idx[b]])
+		})
+		keep := make([]bool, len(poles))
+		for _, i := range idx[:minInt(k, len(idx))] {
+			keep[i] = true
+		}
+		return keep
+	}
+}
+
+//ModalTruncation returns the system with the eigenmodes removed which are
+//not selected, and the poles of the removed modes. The kept states are the
+//real modal coordinates of the selected modes.
+//The system matrix A must be diagonalizable. Input and output delays are kept.
+func (s *System) ModalTruncation(keep ModeSelector) (*System, []complex128, error) {
+	r, removed, err := modalReduction(s.realization(), 0, keep, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.system()
+	return sys, removed, err
+}
+
+//ModalResidualization returns the system with the eigenmodes replaced by their
+//steady state which are not selected, and the poles of the removed modes.
+//Unlike ModalTruncation, the DC gain is preserved.
+func (s *System) ModalResidualization(keep ModeSelector) (*System, []complex128, error) {
+	r, removed, err := modalReduction(s.realization(), 0, keep, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.system()
+	return sys, removed, err
+}
+
+//ModalTruncation returns the discrete system with the eigenmodes removed which
+//are not selected, and the poles z of the removed modes. The selector receives
+//the equivalent poles s = log(z) / Dt, see System.ModalTruncation.
+func (d *Discrete) ModalTruncation(keep ModeSelector) (*Discrete, []complex128, error) {
+	if d.Dt <= 0 {
+		return nil, nil, errors.New("sample time Dt should be positive")
+	}
+	r, removed, err := modalReduction(d.realization(), d.Dt, keep, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.discrete(d.Dt)
+	return sys, removed, err
+}
+
+//ModalResidualization returns the discrete system with the eigenmodes replaced
+//by their steady state which are not selected, and the poles z of the removed
+//modes, see Discrete.ModalTruncation.
+func (d *Discrete) ModalResidualization(keep ModeSelector) (*Discrete, []complex128, error) {
+	if d.Dt <= 0 {
+		return nil, nil, errors.New("sample time Dt should be positive")
+	}
+	r, removed, err := modalReduction(d.realization(), d.Dt, keep, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	sys, err := r.discrete(d.Dt)
+	return sys, removed, err
+}
+
+// modalReduction transforms r into real modal coordinates with the selected
+// modes first and truncates or residualizes the others; dt is zero for
+// time-continuous systems
+func modalReduction(r realization, dt float64, selector ModeSelector, perturb bool) (realization, []complex128, error) {
+	n, _, _ := r.dims()
+
+	var eig mat.Eigen
+	if ok := eig.Factorize(r.a, mat.EigenRight); !ok {
+		return realization{}, nil, errors.New("modal reduction: eigen decomposition failed")
+	}
+	values := eig.Values(nil)
+	var vectors mat.CDense
+	eig.VectorsTo(&vectors)
+
+	// modes: real poles or complex pairs with positive imaginary part
+	type mode struct {
+		pole    complex128
+		columns int
+		index   int
+	}
+	var modes []mode
+	poles := make([]complex128, 0, n)
+	conjugates := 0
+	for i, v := range values {
+		if imag(v) < 0 {
+			conjugates++
+			continue
+		}
+		m := mode{pole: v, columns: 1, index: i}
+		if imag(v) > 0 {
+			m.columns = 2
+		}
+		modes = append(modes, m)
+		p := v
+		if dt > 0 {
+			p = cmplx.Log(v) / complex(dt, 0)
+		}
+		poles = append(poles, p)
+	}
+
+	keep := selector(poles)
+	if len(keep) != len(poles) {
+		return realization{}, nil, errors.New("modal reduction: selector returns wrong number of values")
+	}
+	if 2*conjugates != n-countReal(values) {
+		return realization{}, nil, errors.New("modal reduction: eigenvalues are not in conjugate pairs")
+	}
+
+	// real modal basis with the kept modes first
+	t := mat.NewDense(n, n, nil)
+	col, order := 0, 0
+	var removed []complex128
+	for pass := 0; pass < 2; pass++ {
+		for k, m := range modes {
+			if keep[k] != (pass == 0) {
+				continue
+			}
+			for i := 0; i < n; i++ {
+				v := vectors.At(i, m.index)
+				t.Set(i, col, real(v))
+				if m.columns == 2 {
+					t.Set(i, col+1, imag(v))
+				}
+			}
+			col += m.columns
+			if pass == 0 {
+				order += m.columns
+			} else {
+				removed = append(removed, m.pole)
+				if m.columns == 2 {
+					removed = append(removed, cmplx.Conj(m.pole))
+				}
+			}
+		}
+	}
+	if order == 0 {
+		return realization{}, nil, errors.New("modal reduction: no modes are kept")
+	}
+
+	var tinv mat.Dense
+	if err := tinv.Inverse(t); err != nil {
+		return realization{}, nil, errors.New("modal reduction: system matrix is not diagonalizable")
+	}
+	modal := project(r, t, &tinv)
+	if order == n {
+		return modal, nil, nil
+	}
+
+	if perturb {
+		res, err := residualize(modal, order, dt > 0)
+		if err != nil {
+			return realization{}, nil, err
+		}
+		return res, removed, nil
+	}
+	_, m, p := modal.dims()
+	return realization{
+		a:           mat.DenseCopyOf(modal.a.Slice(0, order, 0, order)),
+		b:           mat.DenseCopyOf(modal.b.Slice(0, order, 0, m)),
+		c:           mat.DenseCopyOf(modal.c.Slice(0, p, 0, order)),
+		d:           modal.d,
+		inputs:      modal.inputs,
+		outputs:     modal.outputs,
+		inputDelay:  modal.inputDelay,
+		outputDelay: modal.outputDelay,
+	}, removed, nil
+}
+
+// countReal returns the number of real values
+func countReal(values []complex128) int {
+	count := 0
+	for _, v := range values {
+		if imag(v) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// minInt returns the minimum of a and b
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
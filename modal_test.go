@@ -0,0 +1,92 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"testing"
+)
+
+func TestModalReduction(t *testing.T) {
+	sys := newTestFourthOrder(t)
+
+	trunc, removed, err := sys.ModalTruncation(SlowestModes(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _, err := sys.ModalResidualization(SlowestModes(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := []float64{real(removed[0]), real(removed[1])}
+	sort.Float64s(re)
+	if len(removed) != 2 || math.Abs(re[0]+50) > 1e-9 || math.Abs(re[1]+10) > 1e-9 {
+		t.Error("wrong removed modes:", removed)
+	}
+
+	for _, w := range []float64{0, 1, 5} {
+		s := complex(0, w)
+		slow := 1/(s+1) + 0.5/(s+3)
+		if h := sisoAt(t, trunc, w); cmplx.Abs(h-slow) > 1e-10 {
+			fmt.Println("received:", h, "expected:", slow)
+			t.Error("wrong modal truncation")
+		}
+		if h, e := sisoAt(t, res, w), slow+0.1/10+0.01/50; cmplx.Abs(h-e) > 1e-10 {
+			fmt.Println("received:", h, "expected:", e)
+			t.Error("wrong modal residualization")
+		}
+	}
+
+	// complex pair is kept as a real 2x2 block
+	osc, err := newTestSecondOrder(1, 0.2, 0).Parallel(newTestFirstOrder(1, 20, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, removed, err := osc.ModalTruncation(PolesInRegion(func(p complex128) bool { return real(p) > -5 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := slow.A.Dims(); n != 2 || len(removed) != 1 {
+		t.Error("wrong modal truncation of oscillating system:", n, removed)
+	}
+	if h, e := sisoAt(t, slow, 0.7), sisoAt(t, newTestSecondOrder(1, 0.2, 0), 0.7); cmplx.Abs(h-e) > 1e-10 {
+		t.Error("wrong response of kept complex pair")
+	}
+
+	// the slowest mode is the pair, which keeps two states
+	slow, _, err = osc.ModalTruncation(SlowestModes(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := slow.A.Dims(); n != 2 {
+		t.Error("SlowestModes(1) should keep the complex pair, received states:", n)
+	}
+
+	if _, _, err := sys.ModalTruncation(PolesInRegion(func(p complex128) bool { return false })); err == nil {
+		t.Error("expected error if no modes are kept")
+	}
+}
+
+func TestModalReductionDiscrete(t *testing.T) {
+	dt := 0.05
+	disc, err := newTestFourthOrder(t).Discretize(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, removed, err := disc.ModalResidualization(SlowestModes(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.Ad.Dims(); n != 2 || len(removed) != 2 {
+		t.Fatal("wrong discrete modal residualization")
+	}
+	for _, z := range removed {
+		if math.Abs(real(z)-math.Exp(-10*dt)) > 1e-9 && math.Abs(real(z)-math.Exp(-50*dt)) > 1e-9 {
+			t.Error("wrong removed mode:", z)
+		}
+	}
+	if h, e := sisoAt(t, res, 0), sisoAt(t, disc, 0); cmplx.Abs(h-e) > 1e-10 {
+		t.Error("discrete ModalResidualization should preserve the DC gain:", h, e)
+	}
+}
@@ -0,0 +1,360 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//MPCWeights contains the weights of the MPC cost
+// 	J = Sum_k=1..N (y(k) - r(k))^T Q (y(k) - r(k)) + Sum_k=0..N-1 u(k)^T R u(k) + du(k)^T S du(k)
+//with the input changes du(k) = u(k) - u(k-1).
+//
+// The fields are:
+// 	Output: Q (p x p)
+// 	Input:  R (m x m), may be nil
+// 	Rate:   S (m x m), may be nil
+//
+type MPCWeights struct {
+	Output *mat.Dense
+	Input  *mat.Dense
+	Rate   *mat.Dense
+}
+
+//MPCConstraints contains the bounds of the inputs, input changes and outputs
+//over the horizon. Nil bounds are not constrained; single channels can be
+//left unconstrained with -Inf or +Inf.
+type MPCConstraints struct {
+	InputMin, InputMax   []float64
+	RateMin, RateMax     []float64
+	OutputMin, OutputMax []float64
+}
+
+//MPC is a linear model predictive controller of a strictly proper discrete plant.
+//The predictions y(k) = C * x(k), k = 1..N, are stacked into
+// 	Y = Psi * x(0) + Theta * U
+//with the inputs U = [u(0); ...; u(N-1)], and the quadratic program in U is
+//solved in every step with a QPSolver, which is warm started by the shifted
+//solution of the previous step. The ADMM method is used by default.
+type MPC struct {
+	Plant       *Discrete
+	Horizon     int
+	Weights     MPCWeights
+	Constraints MPCConstraints
+
+	psi, theta *mat.Dense // Prediction matrices
+	diff       *mat.Dense // Input changes dU = diff * U - E u(k-1)
+	qbar, sbar *mat.Dense // Block diagonal weights
+	qp         *QPSolver
+	rows       []constraintRows
+	bounds     QPBounds
+	lastU      *mat.VecDense
+	plan       *mat.VecDense
+}
+
+// constraintRows describes a group of constraint rows of the QP
+type constraintRows struct {
+	kind         int // 1: rates, 2: outputs
+	start        int
+	lower, upper []float64
+}
+
+//NewMPC returns a model predictive controller for the plant with the
+//prediction horizon N and the given weights and constraints.
+func NewMPC(plant *Discrete, horizon int, weights MPCWeights, constraints MPCConstraints) (*MPC, error) {
+	if horizon < 1 {
+		return nil, errors.New("MPC: horizon should be positive")
+	}
+	n, m := plant.Bd.Dims()
+	p, _ := plant.C.Dims()
+	if mat.Norm(plant.D, 1) != 0 {
+		return nil, errors.New("MPC: plant should be strictly proper (D = 0)")
+	}
+	if err := checkWeight(weights.Output, p, false); err != nil {
+		return nil, err
+	}
+	if err := checkWeight(weights.Input, m, true); err != nil {
+		return nil, err
+	}
+	if err := checkWeight(weights.Rate, m, true); err != nil {
+		return nil, err
+	}
+
+	c := &MPC{
+		Plant:       plant,
+		Horizon:     horizon,
+		Weights:     weights,
+		Constraints: constraints,
+		lastU:       mat.NewVecDense(m, nil),
+	}
+	nu := horizon * m
+
+	// Psi = [C A; C A^2; ...], Theta_ij = C A^(i-j) B for j <= i
+	c.psi = mat.NewDense(horizon*p, n, nil)
+	c.theta = mat.NewDense(horizon*p, nu, nil)
+	var ca mat.Dense
+	ca.CloneFrom(plant.C)
+	for i := 0; i < horizon; i++ {
+		var cab mat.Dense
+		cab.Mul(&ca, plant.Bd)
+		for j := i; j < horizon; j++ {
+			setBlock(c.theta, j*p, (j-i)*m, &cab)
+		}
+		ca.Mul(&ca, plant.Ad)
+		setBlock(c.psi, i*p, 0, &ca)
+	}
+
+	// dU = diff * U - E u(k-1)
+	c.diff = mat.NewDense(nu, nu, nil)
+	setIdentity(c.diff, 0, 0, nu)
+	for i := 1; i < horizon; i++ {
+		for k := 0; k < m; k++ {
+			c.diff.Set(i*m+k, (i-1)*m+k, -1)
+		}
+	}
+
+	// H = Theta^T Qbar Theta + Rbar + diff^T Sbar diff
+	c.qbar = blockRepeat(weights.Output, horizon)
+	var tq, hd mat.Dense
+	tq.Mul(c.theta.T(), c.qbar)
+	hd.Mul(&tq, c.theta)
+	if weights.Input != nil {
+		hd.Add(&hd, blockRepeat(weights.Input, horizon))
+	}
+	if weights.Rate != nil {
+		c.sbar = blockRepeat(weights.Rate, horizon)
+		var ds, dsd mat.Dense
+		ds.Mul(c.diff.T(), c.sbar)
+		dsd.Mul(&ds, c.diff)
+		hd.Add(&hd, &dsd)
+	}
+	h := mat.NewSymDense(nu, nil)
+	for i := 0; i < nu; i++ {
+		for j := i; j < nu; j++ {
+			h.SetSym(i, j, 0.5*(hd.At(i, j)+hd.At(j, i)))
+		}
+	}
+
+	// constraint matrix
+	var blocks []*mat.Dense
+	total := 0
+	add := func(kind int, lower, upper []float64, size int, a *mat.Dense) error {
+		if lower == nil && upper == nil {
+			return nil
+		}
+		if err := checkBounds(lower, size); err != nil {
+			return err
+		}
+		if err := checkBounds(upper, size); err != nil {
+			return err
+		}
+		c.rows = append(c.rows, constraintRows{kind: kind, start: total, lower: lower, upper: upper})
+		blocks = append(blocks, a)
+		r, _ := a.Dims()
+		total += r
+		return nil
+	}
+	// input bounds are box constraints of U
+	if err := checkBounds(constraints.InputMin, m); err != nil {
+		return nil, err
+	}
+	if err := checkBounds(constraints.InputMax, m); err != nil {
+		return nil, err
+	}
+	c.bounds.XMin = repeatBounds(constraints.InputMin, horizon)
+	c.bounds.XMax = repeatBounds(constraints.InputMax, horizon)
+	if err := add(1, constraints.RateMin, constraints.RateMax, m, c.diff); err != nil {
+		return nil, err
+	}
+	if err := add(2, constraints.OutputMin, constraints.OutputMax, p, c.theta); err != nil {
+		return nil, err
+	}
+	var a *mat.Dense
+	if total > 0 {
+		a = mat.NewDense(total, nu, nil)
+		row := 0
+		for _, b := range blocks {
+			setBlock(a, row, 0, b)
+			r, _ := b.Dims()
+			row += r
+		}
+	}
+	qp, err := NewQPSolver(h, a, QPOptions{})
+	if err != nil {
+		return nil, err
+	}
+	c.qp = qp
+	if a != nil {
+		c.bounds.Lower = make([]float64, total)
+		c.bounds.Upper = make([]float64, total)
+	}
+	c.plan = mat.NewVecDense(nu, nil)
+	return c, nil
+}
+
+//SetSolver replaces the QP solver by a solver with the given options.
+func (c *MPC) SetSolver(opts QPOptions) error {
+	qp, err := NewQPSolver(c.qp.H, c.qp.A, opts)
+	if err != nil {
+		return err
+	}
+	c.qp = qp
+	return nil
+}
+
+//SetLastInput sets the input u(k-1) applied before the next step, which
+//enters the cost and the constraints of the input changes.
+func (c *MPC) SetLastInput(u *mat.VecDense) {
+	c.lastU.CopyVec(u)
+}
+
+//Control solves the MPC problem for the state x and the reference r and
+//returns the first input u(0) of the optimal plan, which is stored as the
+//last input of the next step. r contains either one reference for all
+//steps (p) or the references of every step (N * p). If the solver does not
+//converge, the last iterate is returned together with ErrNotConverged.
+func (c *MPC) Control(x, r *mat.VecDense) (*mat.VecDense, error) {
+	_, m := c.Plant.Bd.Dims()
+	p, _ := c.Plant.C.Dims()
+	nu := c.Horizon * m
+
+	// stacked reference
+	rbar := mat.NewVecDense(c.Horizon*p, nil)
+	switch r.Len() {
+	case p:
+		for i := 0; i < c.Horizon; i++ {
+			for k := 0; k < p; k++ {
+				rbar.SetVec(i*p+k, r.AtVec(k))
+			}
+		}
+	case c.Horizon * p:
+		rbar.CopyVec(r)
+	default:
+		return nil, errors.New("MPC: reference should have p or N * p elements")
+	}
+
+	// f = Theta^T Qbar (Psi x - rbar) - diff^T Sbar E u(k-1)
+	var free, e, qe, f mat.VecDense
+	free.MulVec(c.psi, x)
+	e.SubVec(&free, rbar)
+	qe.MulVec(c.qbar, &e)
+	f.MulVec(c.theta.T(), &qe)
+	if c.sbar != nil {
+		eu := mat.NewVecDense(nu, nil)
+		for k := 0; k < m; k++ {
+			eu.SetVec(k, c.lastU.AtVec(k))
+		}
+		var seu, dseu mat.VecDense
+		seu.MulVec(c.sbar, eu)
+		dseu.MulVec(c.diff.T(), &seu)
+		f.SubVec(&f, &dseu)
+	}
+
+	// bounds of the constraint rows
+	for _, g := range c.rows {
+		size := m
+		if g.kind == 2 {
+			size = p
+		}
+		for i := 0; i < c.Horizon; i++ {
+			for k := 0; k < size; k++ {
+				lo, hi := math.Inf(-1), math.Inf(1)
+				if g.lower != nil {
+					lo = g.lower[k]
+				}
+				if g.upper != nil {
+					hi = g.upper[k]
+				}
+				switch {
+				case g.kind == 1 && i == 0:
+					// du(0) = u(0) - u(k-1)
+					lo += c.lastU.AtVec(k)
+					hi += c.lastU.AtVec(k)
+				case g.kind == 2:
+					// Y = Psi x + Theta U
+					lo -= free.AtVec(i*p + k)
+					hi -= free.AtVec(i*p + k)
+				}
+				c.bounds.Lower[g.start+i*size+k] = lo
+				c.bounds.Upper[g.start+i*size+k] = hi
+			}
+		}
+	}
+
+	// warm start with the shifted plan of the previous step
+	shifted := mat.NewVecDense(nu, nil)
+	for i := 0; i < nu; i++ {
+		src := i + m
+		if src >= nu {
+			src = i
+		}
+		shifted.SetVec(i, c.plan.AtVec(src))
+	}
+	c.qp.WarmStart(shifted)
+
+	plan, err := c.qp.Solve(&f, c.bounds)
+	if err != nil && err != ErrNotConverged {
+		return nil, err
+	}
+	c.plan.CopyVec(plan)
+	u := mat.NewVecDense(m, nil)
+	for k := 0; k < m; k++ {
+		u.SetVec(k, c.plan.AtVec(k))
+	}
+	c.lastU.CopyVec(u)
+	return u, err
+}
+
+//Plan returns a copy of the optimal inputs U = [u(0); ...; u(N-1)] of the last step.
+func (c *MPC) Plan() *mat.VecDense {
+	return mat.VecDenseCopyOf(c.plan)
+}
+
+//Iterations returns the number of solver iterations of the last step.
+func (c *MPC) Iterations() int {
+	return c.qp.Iterations()
+}
+
+// checkWeight checks that the weight is square with the size n
+func checkWeight(w *mat.Dense, n int, optional bool) error {
+	if w == nil {
+		if optional {
+			return nil
+		}
+		return errors.New("MPC: output weight should not be nil")
+	}
+	if r, c := w.Dims(); r != n || c != n {
+		return errors.New("MPC: weights should be square with the number of channels")
+	}
+	return nil
+}
+
+// checkBounds checks that the bounds are nil or have n elements
+func checkBounds(bounds []float64, n int) error {
+	if bounds != nil && len(bounds) != n {
+		return errors.New("MPC: constraints should have a bound for every channel")
+	}
+	return nil
+}
+
+// repeatBounds returns the bounds repeated n times or nil
+func repeatBounds(bounds []float64, n int) []float64 {
+	if bounds == nil {
+		return nil
+	}
+	res := make([]float64, 0, n*len(bounds))
+	for i := 0; i < n; i++ {
+		res = append(res, bounds...)
+	}
+	return res
+}
+
+// blockRepeat returns the block diagonal matrix with n copies of w
+func blockRepeat(w *mat.Dense, n int) *mat.Dense {
+	ws := make([]*mat.Dense, n)
+	for i := range ws {
+		ws[i] = w
+	}
+	return blockDiag(ws...)
+}
@@ -0,0 +1,119 @@
+package lti
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestMPCUnconstrained(t *testing.T) {
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := MPCWeights{
+		Output: mat.NewDense(1, 1, []float64{1}),
+		Input:  mat.NewDense(1, 1, []float64{0.01}),
+	}
+	mpc, err := NewMPC(plant, 10, weights, MPCConstraints{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// direct least-squares solution of the unconstrained problem
+	x := mat.NewVecDense(2, []float64{0.5, -0.2})
+	r := mat.NewVecDense(1, []float64{1})
+	if _, err := mpc.Control(x, r); err != nil {
+		t.Fatal(err)
+	}
+
+	// minimize |Psi x + Theta U - r|^2 + 0.01 |U|^2
+	n := 10
+	a := mat.NewDense(2*n, n, nil)
+	b := mat.NewVecDense(2*n, nil)
+	var free mat.VecDense
+	free.MulVec(mpc.psi, x)
+	setBlock(a, 0, 0, mpc.theta)
+	for i := 0; i < n; i++ {
+		a.Set(n+i, i, 0.1)
+		b.SetVec(i, 1-free.AtVec(i))
+	}
+	expected, err := leastSquares(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(mpc.Plan(), expected, 1e-9) {
+		t.Error("unconstrained MPC returned wrong plan:", mpc.Plan(), expected)
+	}
+}
+
+func TestMPCConstrained(t *testing.T) {
+	for _, method := range []QPMethod{ADMM, ActiveSet} {
+		testMPCConstrained(t, method)
+	}
+}
+
+func testMPCConstrained(t *testing.T, method QPMethod) {
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := MPCWeights{
+		Output: mat.NewDense(1, 1, []float64{10}),
+		Input:  mat.NewDense(1, 1, []float64{0.01}),
+		Rate:   mat.NewDense(1, 1, []float64{0.1}),
+	}
+	constraints := MPCConstraints{
+		InputMin:  []float64{-0.5},
+		InputMax:  []float64{0.5},
+		RateMax:   []float64{0.2},
+		OutputMax: []float64{0.8},
+	}
+	mpc, err := NewMPC(plant, 20, weights, constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mpc.SetSolver(QPOptions{Method: method}); err != nil {
+		t.Fatal(err)
+	}
+
+	x := mat.NewVecDense(2, nil)
+	r := mat.NewVecDense(1, []float64{1})
+	last := 0.0
+	tol := 1e-4
+	for k := 0; k < 300; k++ {
+		u, err := mpc.Control(x, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.AtVec(0) > 0.5+tol || u.AtVec(0) < -0.5-tol || u.AtVec(0)-last > 0.2+tol {
+			t.Fatal("MPC violates the input constraints with method", method, "at step", k, ":", u.AtVec(0))
+		}
+		last = u.AtVec(0)
+		x = mat.VecDenseCopyOf(plant.Predict(x, u))
+		if y := plant.Response(x, u).AtVec(0); y > 0.8+1e-3 {
+			t.Fatal("MPC violates the output constraint with method", method, "at step", k, ":", y)
+		}
+	}
+	if y := x.AtVec(0); math.Abs(y-0.8) > 1e-3 {
+		t.Error("MPC should settle at the output constraint with method", method, ", received:", y)
+	}
+	if mpc.Iterations() == 0 {
+		t.Error("constrained MPC should iterate")
+	}
+}
+
+func TestMPCErrors(t *testing.T) {
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMPC(plant, 10, MPCWeights{}, MPCConstraints{}); err == nil {
+		t.Error("expected error without output weight")
+	}
+	weights := MPCWeights{Output: mat.NewDense(1, 1, []float64{1})}
+	if _, err := NewMPC(plant, 10, weights, MPCConstraints{InputMax: []float64{1, 2}}); err == nil {
+		t.Error("expected error for wrong number of bounds")
+	}
+}
@@ -0,0 +1,289 @@
+package lti
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// hinfTolerance is the relative accuracy of the H-infinity norm
+const hinfTolerance = 1e-8
+
+//H2Norm returns the H2 norm sqrt(trace(C * Wc * C^T)) of the system
+//with the controllability Gramian Wc. Unstable systems and systems
+//with a non-zero feedforward matrix D have an infinite H2 norm.
+//Input and output delays multiply G(jw) with diagonal unitary matrices
+//from the left and right, which change neither the H2 nor the H-infinity norm.
+func (s *System) H2Norm() (float64, error) {
+	ev, err := eigenvalues(s.A)
+	if err != nil {
+		return 0, err
+	}
+	if !stableContinuous(ev) || mat.Norm(s.D, 1) > 0 {
+		return math.Inf(1), nil
+	}
+
+	wc, err := Lyapunov(s.A, outerProduct(s.B))
+	if err != nil {
+		return 0, err
+	}
+	p, err := outputCovariance(s.C, s.D, wc, nil)
+	if err != nil {
+		return 0, err
+	}
+	return math.Sqrt(p.Trace()), nil
+}
+
+//HinfNorm returns the H-infinity norm of the system, i.e. the peak of the
+//largest singular value of G(jw), and the frequency (rad/s) of the peak.
+//The norm is calculated by the Hamiltonian bisection of Boyd, Balakrishnan,
+//Bruinsma and Steinbuch. Unstable systems have an infinite norm.
+func (s *System) HinfNorm() (float64, float64, error) {
+	ev, err := eigenvalues(s.A)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !stableContinuous(ev) {
+		return math.Inf(1), math.NaN(), nil
+	}
+	return hinfContinuous(s.A, s.B, s.C, s.D, ev)
+}
+
+//H2Norm returns the H2 norm sqrt(trace(C * Wc * C^T + D * D^T)) of the
+//discrete system with the controllability Gramian Wc.
+//Unstable systems have an infinite H2 norm.
+func (d *Discrete) H2Norm() (float64, error) {
+	ev, err := eigenvalues(d.Ad)
+	if err != nil {
+		return 0, err
+	}
+	if !stableDiscrete(ev) {
+		return math.Inf(1), nil
+	}
+
+	wc, err := DiscreteLyapunov(d.Ad, outerProduct(d.Bd))
+	if err != nil {
+		return 0, err
+	}
+	_, m := d.D.Dims()
+	id := mat.NewDiagDense(m, nil)
+	for i := 0; i < m; i++ {
+		id.SetDiag(i, 1)
+	}
+	p, err := outputCovariance(d.C, d.D, wc, id)
+	if err != nil {
+		return 0, err
+	}
+	return math.Sqrt(p.Trace()), nil
+}
+
+//HinfNorm returns the H-infinity norm of the discrete system, i.e. the peak
+//of the largest singular value of G(exp(jw Dt)), and the frequency (rad/s)
+//of the peak. The system is mapped by the bilinear transformation
+//z = (1 + s) / (1 - s), which preserves the norm, onto a time-continuous
+//system. Unstable systems have an infinite norm.
+func (d *Discrete) HinfNorm() (float64, float64, error) {
+	ev, err := eigenvalues(d.Ad)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !stableDiscrete(ev) {
+		return math.Inf(1), math.NaN(), nil
+	}
+	if d.Dt <= 0 {
+		return 0, 0, errors.New("sample time Dt should be positive")
+	}
+
+	// (A_d + I)^-1
+	n, _ := d.Ad.Dims()
+	var api, inv mat.Dense
+	api.CloneFrom(d.Ad)
+	for i := 0; i < n; i++ {
+		api.Set(i, i, api.At(i, i)+1)
+	}
+	if err := inv.Inverse(&api); err != nil {
+		return 0, 0, errors.New("HinfNorm: bilinear transformation failed")
+	}
+
+	// Ac = (A_d + I)^-1 (A_d - I), Bc = sqrt(2) (A_d + I)^-1 B_d,
+	// Cc = sqrt(2) C (A_d + I)^-1, Dc = D - C (A_d + I)^-1 B_d
+	var ami, ac, bc, cc, cib, dc mat.Dense
+	ami.CloneFrom(d.Ad)
+	for i := 0; i < n; i++ {
+		ami.Set(i, i, ami.At(i, i)-1)
+	}
+	ac.Mul(&inv, &ami)
+	bc.Mul(&inv, d.Bd)
+	cc.Mul(d.C, &inv)
+	cib.Mul(&cc, d.Bd)
+	dc.Sub(d.D, &cib)
+	bc.Scale(math.Sqrt2, &bc)
+	cc.Scale(math.Sqrt2, &cc)
+
+	evc, err := eigenvalues(&ac)
+	if err != nil {
+		return 0, 0, err
+	}
+	gamma, wc, err := hinfContinuous(&ac, &bc, &cc, &dc, evc)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// s = j tan(w Dt / 2)
+	return gamma, 2 * math.Atan(wc) / d.Dt, nil
+}
+
+// hinfContinuous calculates the H-infinity norm of a stable time-continuous
+// system with the eigenvalues ev of A
+func hinfContinuous(a, b, c, d *mat.Dense, ev []complex128) (float64, float64, error) {
+	e, err := newFreqEvaluator(a, b, c, d, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	g := mat.NewCDense(e.p, e.m, nil)
+	sigma := func(w float64) float64 {
+		if err := e.evalTo(g, complex(0, w)); err != nil {
+			return math.Inf(1)
+		}
+		return maxSingularValue(g)
+	}
+
+	// initial lower bound from D, the DC gain and the natural frequencies of the poles
+	gammaLB, peak := maxSingularValue(realToComplex(d)), math.Inf(1)
+	candidates := []float64{0}
+	for _, v := range ev {
+		candidates = append(candidates, math.Hypot(real(v), imag(v)))
+	}
+	for _, w := range candidates {
+		if sv := sigma(w); sv > gammaLB {
+			gammaLB, peak = sv, w
+		}
+	}
+	if gammaLB == 0 {
+		return 0, 0, nil
+	}
+
+	for iter := 0; iter < 100; iter++ {
+		gamma := (1 + 2*hinfTolerance) * gammaLB
+		omegas, err := imaginaryHamiltonianEigenvalues(a, b, c, d, gamma)
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(omegas) == 0 {
+			return gammaLB, peak, nil
+		}
+
+		// evaluate at the midpoints of the intervals where sigma > gamma
+		improved := false
+		if len(omegas) == 1 {
+			omegas = append(omegas, omegas[0])
+		}
+		for k := 0; k < len(omegas)-1; k++ {
+			w := 0.5 * (omegas[k] + omegas[k+1])
+			if sv := sigma(w); sv > gammaLB {
+				gammaLB, peak = sv, w
+				improved = true
+			}
+		}
+		if !improved {
+			return gammaLB, peak, nil
+		}
+	}
+	return gammaLB, peak, nil
+}
+
+// imaginaryHamiltonianEigenvalues returns the sorted non-negative frequencies w
+// for which jw is an eigenvalue of the Hamiltonian matrix of the level gamma.
+// These are the frequencies where gamma is a singular value of G(jw).
+func imaginaryHamiltonianEigenvalues(a, b, c, d *mat.Dense, gamma float64) ([]float64, error) {
+	n, _ := a.Dims()
+	_, m := b.Dims()
+	p, _ := c.Dims()
+
+	// R = gamma^2 I - D^T D
+	var r, rinv mat.Dense
+	r.Mul(d.T(), d)
+	r.Scale(-1, &r)
+	for i := 0; i < m; i++ {
+		r.Set(i, i, r.At(i, i)+gamma*gamma)
+	}
+	if err := rinv.Inverse(&r); err != nil {
+		return nil, errors.New("HinfNorm: gamma is a singular value of D")
+	}
+
+	// H11 = A + B R^-1 D^T C
+	var brinv, brdt, h11, h12, h21 mat.Dense
+	brinv.Mul(b, &rinv)
+	brdt.Mul(&brinv, d.T())
+	h11.Mul(&brdt, c)
+	h11.Add(&h11, a)
+
+	// H12 = B R^-1 B^T
+	h12.Mul(&brinv, b.T())
+
+	// H21 = -C^T (I + D R^-1 D^T) C
+	var drdt, ct mat.Dense
+	drdt.Mul(d, &rinv)
+	drdt.Mul(&drdt, d.T())
+	for i := 0; i < p; i++ {
+		drdt.Set(i, i, drdt.At(i, i)+1)
+	}
+	ct.Mul(c.T(), &drdt)
+	h21.Mul(&ct, c)
+	h21.Scale(-1, &h21)
+
+	h := mat.NewDense(2*n, 2*n, nil)
+	h.Slice(0, n, 0, n).(*mat.Dense).Copy(&h11)
+	h.Slice(0, n, n, 2*n).(*mat.Dense).Copy(&h12)
+	h.Slice(n, 2*n, 0, n).(*mat.Dense).Copy(&h21)
+	h.Slice(n, 2*n, n, 2*n).(*mat.Dense).Scale(-1, h11.T())
+
+	ev, err := eigenvalues(h)
+	if err != nil {
+		return nil, err
+	}
+
+	tol := 1e-8 * math.Max(1, mat.Norm(h, 1))
+	var omegas []float64
+	for _, v := range ev {
+		if imag(v) < 0 {
+			continue
+		}
+		if math.Abs(real(v)) < tol {
+			omegas = append(omegas, imag(v))
+		}
+	}
+	sort.Float64s(omegas)
+	return omegas, nil
+}
+
+// outerProduct returns B * B^T
+func outerProduct(b *mat.Dense) *mat.SymDense {
+	var bbt mat.SymDense
+	bbt.SymOuterK(1, b)
+	return &bbt
+}
+
+// maxSingularValue returns the largest singular value of the complex matrix m
+// from its real embedding which has every singular value twice
+func maxSingularValue(m *mat.CDense) float64 {
+	var svd mat.SVD
+	if ok := svd.Factorize(realEmbedding(m), mat.SVDNone); !ok {
+		return math.NaN()
+	}
+	return svd.Values(nil)[0]
+}
+
+// realToComplex converts a real matrix into a complex matrix
+func realToComplex(m *mat.Dense) *mat.CDense {
+	r, c := m.Dims()
+	cm := mat.NewCDense(r, c, nil)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			cm.Set(i, j, complex(m.At(i, j), 0))
+		}
+	}
+	return cm
+}
@@ -0,0 +1,126 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// newTestSecondOrder returns wn^2 / (s^2 + 2 zeta wn s + wn^2) + d
+func newTestSecondOrder(wn, zeta, d float64) *System {
+	sys, _ := NewSystem(
+		mat.NewDense(2, 2, []float64{0, 1, -wn * wn, -2 * zeta * wn}),
+		mat.NewDense(2, 1, []float64{0, 1}),
+		mat.NewDense(1, 2, []float64{wn * wn, 0}),
+		mat.NewDense(1, 1, []float64{d}),
+	)
+	return sys
+}
+
+func TestH2Norm(t *testing.T) {
+	// G(s) = 3 / (s + 2), ||G||_2 = 3 / sqrt(4)
+	sys, _ := NewSystem(
+		mat.NewDense(1, 1, []float64{-2}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{3}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	h2, err := sys.H2Norm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(h2-1.5) > 1e-12 {
+		t.Error("wrong H2 norm:", h2)
+	}
+
+	// feedforward and unstable systems
+	sys.D.Set(0, 0, 1)
+	if h2, _ := sys.H2Norm(); !math.IsInf(h2, 1) {
+		t.Error("expected infinite H2 norm with feedforward, received", h2)
+	}
+	unstable, _ := NewTestSystem()
+	if h2, _ := unstable.H2Norm(); !math.IsInf(h2, 1) {
+		t.Error("expected infinite H2 norm for unstable system, received", h2)
+	}
+
+	// discrete first order x(k+1) = 0.5 x(k) + u(k), y = x + 2 u:
+	// impulse response 2, 1, 0.5, 0.25, ...
+	disc := &Discrete{
+		Ad: mat.NewDense(1, 1, []float64{0.5}),
+		Bd: mat.NewDense(1, 1, []float64{1}),
+		C:  mat.NewDense(1, 1, []float64{1}),
+		D:  mat.NewDense(1, 1, []float64{2}),
+		Dt: 1,
+	}
+	h2, err = disc.H2Norm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := math.Sqrt(4 + 1/(1-0.25)); math.Abs(h2-expected) > 1e-12 {
+		t.Error("wrong discrete H2 norm:", h2, "expected:", expected)
+	}
+}
+
+func TestHinfNorm(t *testing.T) {
+	zeta, wn := 0.1, 3.0
+	sys := newTestSecondOrder(wn, zeta, 0)
+
+	norm, peak, err := sys.HinfNorm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 1 / (2 * zeta * math.Sqrt(1-zeta*zeta))
+	if math.Abs(norm-expected) > 1e-6*expected || math.Abs(peak-wn*math.Sqrt(1-2*zeta*zeta)) > 1e-3 {
+		fmt.Println("received:", norm, peak)
+		t.Error("wrong H-infinity norm")
+	}
+
+	// well damped system with feedforward peaks at DC
+	sys = newTestSecondOrder(wn, 2, 3)
+	norm, peak, err = sys.HinfNorm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(norm-4) > 1e-6 || peak != 0 {
+		fmt.Println("received:", norm, peak)
+		t.Error("wrong H-infinity norm with feedforward")
+	}
+
+	unstable, _ := NewTestSystem()
+	if norm, _, _ := unstable.HinfNorm(); !math.IsInf(norm, 1) {
+		t.Error("expected infinite norm for unstable system, received", norm)
+	}
+}
+
+func TestDiscreteHinfNorm(t *testing.T) {
+	sys := newTestSecondOrder(3, 0.05, 0.2)
+	disc, err := sys.Discretize(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	norm, peak, err := disc.HinfNorm()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// compare with a dense frequency grid
+	h, err := disc.FrequencyResponse(LogSpace(1, 5, 20000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gridMax := 0.0
+	for _, v := range Magnitude(h, 0, 0) {
+		gridMax = math.Max(gridMax, v)
+	}
+	if norm < gridMax || norm > gridMax*(1+1e-5) {
+		fmt.Println("received:", norm, "grid:", gridMax)
+		t.Error("wrong discrete H-infinity norm")
+	}
+	hp, _ := disc.FrequencyResponse([]float64{peak})
+	if math.Abs(Magnitude(hp, 0, 0)[0]-norm) > 1e-6*norm {
+		t.Error("wrong peak frequency:", peak)
+	}
+}
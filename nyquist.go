@@ -0,0 +1,211 @@
+package lti
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// indentationPoints is the number of points on the indentation around a pole
+const indentationPoints = 101
+
+//NyquistData contains the Nyquist curve of a SISO open loop L.
+//
+// The fields are:
+// 	Contour:            Points s (or z) of the Nyquist contour
+// 	Omega:              Frequency (rad/s) of every contour point; the pole frequency on indentations
+// 	Response:           L evaluated on the contour
+// 	Encirclements:      Number of clockwise encirclements N of -1
+// 	OpenLoopUnstable:   Number P of open loop poles in the right half plane (outside the unit circle)
+// 	ClosedLoopUnstable: Number Z = N + P of unstable closed loop poles
+//
+type NyquistData struct {
+	Contour            []complex128
+	Omega              []float64
+	Response           []complex128
+	Encirclements      int
+	OpenLoopUnstable   int
+	ClosedLoopUnstable int
+}
+
+//NicholsData contains the Nichols chart of a SISO open loop L.
+type NicholsData struct {
+	Omega    []float64
+	GainDB   []float64
+	PhaseDeg []float64
+}
+
+//Nyquist evaluates the SISO open loop L along the Nyquist contour with n
+//frequencies per half-axis and counts the encirclements of -1.
+//For time-continuous systems the contour runs along the imaginary axis,
+//for discrete systems along the unit circle. Poles on the contour are
+//excluded by small semicircular indentations, so that they are treated
+//as stable open loop poles.
+func Nyquist(loop FrequencyResponder, n int) (*NyquistData, error) {
+	e, err := evaluatorOf(loop)
+	if err != nil {
+		return nil, err
+	}
+	if e.p != 1 || e.m != 1 {
+		return nil, errors.New("Nyquist: loop should be SISO")
+	}
+	grid, err := loop.FrequencyGrid(n)
+	if err != nil {
+		return nil, err
+	}
+	poles, err := loop.Poles()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &NyquistData{}
+
+	// open loop poles on and inside the contour
+	var axis []float64
+	for _, p := range poles {
+		if e.dt > 0 {
+			abs := cmplx.Abs(p)
+			if math.Abs(abs-1) < 1e-9 {
+				axis = append(axis, cmplx.Phase(p)/e.dt)
+			} else if abs > 1 {
+				data.OpenLoopUnstable++
+			}
+		} else {
+			if math.Abs(real(p)) < 1e-9*math.Max(1, cmplx.Abs(p)) {
+				axis = append(axis, imag(p))
+			} else if real(p) > 0 {
+				data.OpenLoopUnstable++
+			}
+		}
+	}
+	sort.Float64s(axis)
+
+	// frequencies along the contour: -grid ... 0 ... grid
+	omegas := make([]float64, 0, 2*len(grid)+1)
+	for k := len(grid) - 1; k >= 0; k-- {
+		omegas = append(omegas, -grid[k])
+	}
+	omegas = append(omegas, 0)
+	omegas = append(omegas, grid...)
+
+	// radius of the indentations
+	radius := 1e-4 * grid[0]
+	if e.dt > 0 {
+		radius = math.Min(radius*e.dt, 1e-6)
+	}
+
+	nextPole := 0
+	addPoint := func(s complex128, w float64) error {
+		l, err := e.at(s, 0, 0)
+		if err != nil {
+			return err
+		}
+		data.Contour = append(data.Contour, s)
+		data.Omega = append(data.Omega, w)
+		data.Response = append(data.Response, l)
+		return nil
+	}
+	indent := func(w float64) error {
+		for k := 0; k < indentationPoints; k++ {
+			phi := -math.Pi/2 + math.Pi*float64(k)/float64(indentationPoints-1)
+			var s complex128
+			if e.dt > 0 {
+				zp := e.point(w)
+				s = zp + complex(radius, 0)*cmplx.Exp(complex(0, w*e.dt+phi))
+			} else {
+				s = complex(0, w) + complex(radius, 0)*cmplx.Exp(complex(0, phi))
+			}
+			if err := addPoint(s, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// distance of a frequency to a pole on the contour
+	dist := func(w, wp float64) float64 {
+		if e.dt > 0 {
+			return cmplx.Abs(e.point(w) - e.point(wp))
+		}
+		return math.Abs(w - wp)
+	}
+
+	for _, w := range omegas {
+		// indentations of the poles below w
+		for nextPole < len(axis) && axis[nextPole] < w && dist(w, axis[nextPole]) > radius {
+			if err := indent(axis[nextPole]); err != nil {
+				return nil, err
+			}
+			nextPole++
+		}
+		skip := false
+		for _, wp := range axis {
+			if dist(w, wp) <= radius {
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+		if err := addPoint(e.point(w), w); err != nil {
+			return nil, err
+		}
+	}
+	for ; nextPole < len(axis); nextPole++ {
+		if err := indent(axis[nextPole]); err != nil {
+			return nil, err
+		}
+	}
+
+	// winding number of 1 + L around the origin, including the closing segment
+	total := 0.0
+	for k := range data.Response {
+		next := data.Response[(k+1)%len(data.Response)]
+		a, b := 1+data.Response[k], 1+next
+		if a == 0 || b == 0 {
+			return nil, errors.New("Nyquist: curve passes through -1")
+		}
+		total += cmplx.Phase(b / a)
+	}
+	winding := int(math.Floor(total/(2*math.Pi) + 0.5))
+
+	// the contour is traversed clockwise around the unstable region
+	data.Encirclements = -winding
+	data.ClosedLoopUnstable = data.Encirclements + data.OpenLoopUnstable
+
+	return data, nil
+}
+
+//Nichols returns the Nichols chart data (gain in dB over the unwrapped phase in degrees)
+//of the SISO open loop L on a logarithmic grid with n frequencies.
+func Nichols(loop FrequencyResponder, n int) (*NicholsData, error) {
+	e, err := evaluatorOf(loop)
+	if err != nil {
+		return nil, err
+	}
+	if e.p != 1 || e.m != 1 {
+		return nil, errors.New("Nichols: loop should be SISO")
+	}
+	grid, err := loop.FrequencyGrid(n)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &NicholsData{}
+	for _, w := range grid {
+		l, err := e.at(e.point(w), 0, 0)
+		if err != nil {
+			// skip poles on the grid
+			continue
+		}
+		data.Omega = append(data.Omega, w)
+		data.GainDB = append(data.GainDB, 20*math.Log10(cmplx.Abs(l)))
+		data.PhaseDeg = append(data.PhaseDeg, cmplx.Phase(l))
+	}
+	UnwrapPhase(data.PhaseDeg)
+	for k := range data.PhaseDeg {
+		data.PhaseDeg[k] *= 180 / math.Pi
+	}
+	return data, nil
+}
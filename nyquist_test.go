@@ -0,0 +1,86 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestNyquist(t *testing.T) {
+	unstablePlant := func(k float64) *System {
+		// L(s) = k / (s - 1)
+		sys, _ := NewSystem(
+			mat.NewDense(1, 1, []float64{1}),
+			mat.NewDense(1, 1, []float64{1}),
+			mat.NewDense(1, 1, []float64{k}),
+			mat.NewDense(1, 1, []float64{0}),
+		)
+		return sys
+	}
+
+	var config = []struct {
+		Name string
+		Loop FrequencyResponder
+		N, P int
+	}{
+		{Name: "integrating stable", Loop: newTestLoop(2), N: 0, P: 0},
+		{Name: "integrating unstable", Loop: newTestLoop(12), N: 2, P: 0},
+		{Name: "open loop unstable, stabilized", Loop: unstablePlant(2), N: -1, P: 1},
+		{Name: "open loop unstable, not stabilized", Loop: unstablePlant(0.5), N: 0, P: 1},
+	}
+
+	for _, cfg := range config {
+		data, err := Nyquist(cfg.Loop, 500)
+		if err != nil {
+			t.Fatal(cfg.Name, err)
+		}
+		if data.Encirclements != cfg.N || data.OpenLoopUnstable != cfg.P || data.ClosedLoopUnstable != cfg.N+cfg.P {
+			fmt.Printf("%s: N=%d P=%d Z=%d\n", cfg.Name, data.Encirclements, data.OpenLoopUnstable, data.ClosedLoopUnstable)
+			t.Error("wrong encirclements for", cfg.Name)
+		}
+		if len(data.Contour) != len(data.Response) || len(data.Contour) != len(data.Omega) {
+			t.Error("inconsistent Nyquist data for", cfg.Name)
+		}
+	}
+}
+
+func TestNyquistDiscrete(t *testing.T) {
+	for _, cfg := range []struct {
+		K float64
+		Z int
+	}{{K: 2, Z: 0}, {K: 12, Z: 2}} {
+		disc, err := newTestLoop(cfg.K).Discretize(0.05)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := Nyquist(disc, 500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data.ClosedLoopUnstable != cfg.Z || data.OpenLoopUnstable != 0 {
+			fmt.Printf("k=%v: N=%d P=%d Z=%d\n", cfg.K, data.Encirclements, data.OpenLoopUnstable, data.ClosedLoopUnstable)
+			t.Error("wrong encirclements for discrete loop")
+		}
+	}
+}
+
+func TestNichols(t *testing.T) {
+	data, err := Nichols(newTestLoop(2), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// integrator with two lags: -90 deg at low and -270 deg at high frequencies
+	first, last := data.PhaseDeg[0], data.PhaseDeg[len(data.PhaseDeg)-1]
+	if math.Abs(first+90) > 1 || math.Abs(last+270) > 1 {
+		fmt.Println("phase:", first, last)
+		t.Error("wrong unwrapped Nichols phase")
+	}
+	for k := 1; k < len(data.GainDB); k++ {
+		if data.GainDB[k] >= data.GainDB[k-1] {
+			t.Fatal("gain should decrease monotonically")
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package lti
+
+import (
+	"errors"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//SetOperatingPoint sets the operating point of the linearized system with
+//the state x0, the input u0, the output y0 and the derivative dx0 = f(x0, u0).
+//dx0 is zero for an equilibrium. Nil vectors are treated as zero.
+func (s *System) SetOperatingPoint(x0, u0, y0, dx0 *mat.VecDense) error {
+	if err := checkOperatingPoint(s.realization(), x0, u0, y0, dx0); err != nil {
+		return err
+	}
+	s.X0, s.U0, s.Y0, s.Dx0 = x0, u0, y0, dx0
+	return nil
+}
+
+//DerivativeAbsolute returns the derivative x'(t) = dx0 + A * (x(t) - x0) + B * (u(t) - u0)
+//for the absolute state x(t) and input u(t)
+func (s *System) DerivativeAbsolute(x, u *mat.VecDense) *mat.VecDense {
+	dx := multAndSumOp(s.A, deviation(x, s.X0), s.B, deviation(u, s.U0), s.ax, s.bu, s.sum)
+	return addOffset(dx, s.Dx0)
+}
+
+//ResponseAbsolute returns the absolute output y(t) = y0 + C * (x(t) - x0) + D * (u(t) - u0)
+//for the absolute state x(t) and input u(t)
+func (s *System) ResponseAbsolute(x, u *mat.VecDense) *mat.VecDense {
+	y := multAndSumOp(s.C, deviation(x, s.X0), s.D, deviation(u, s.U0), s.ax, s.bu, s.sum)
+	return addOffset(y, s.Y0)
+}
+
+//SetOperatingPoint sets the operating point of the linearized discrete system
+//with the state x0, the input u0, the output y0 and the state change dx0 per
+//sample at the operating point. dx0 is zero for an equilibrium.
+//Nil vectors are treated as zero.
+func (d *Discrete) SetOperatingPoint(x0, u0, y0, dx0 *mat.VecDense) error {
+	if err := checkOperatingPoint(d.realization(), x0, u0, y0, dx0); err != nil {
+		return err
+	}
+	d.X0, d.U0, d.Y0, d.Dx0 = x0, u0, y0, dx0
+	return nil
+}
+
+//PredictAbsolute predicts x(k+1) = x0 + dx0 + A_d * (x(k) - x0) + B_d * (u(k) - u0)
+//for the absolute state x(k) and input u(k)
+func (d *Discrete) PredictAbsolute(x, u *mat.VecDense) *mat.VecDense {
+	next := multAndSumOp(d.Ad, deviation(x, d.X0), d.Bd, deviation(u, d.U0), d.ax, d.bu, d.sum)
+	return addOffset(addOffset(next, d.X0), d.Dx0)
+}
+
+//ResponseAbsolute returns the absolute output y(k) = y0 + C * (x(k) - x0) + D * (u(k) - u0)
+//for the absolute state x(k) and input u(k)
+func (d *Discrete) ResponseAbsolute(x, u *mat.VecDense) *mat.VecDense {
+	y := multAndSumOp(d.C, deviation(x, d.X0), d.D, deviation(u, d.U0), d.ax, d.bu, d.sum)
+	return addOffset(y, d.Y0)
+}
+
+// discretizeOperatingPoint copies the operating point of s and
+// discretizes its derivative, dx0_d = Int_0^T exp(A*t) dt * dx0
+func (d *Discrete) discretizeOperatingPoint(s *System, dt float64) error {
+	d.X0, d.U0, d.Y0 = copyVec(s.X0), copyVec(s.U0), copyVec(s.Y0)
+	if s.Dx0 == nil {
+		return nil
+	}
+	n := s.Dx0.Len()
+	dx0, err := integrate(s.A, mat.NewDense(n, 1, mat.Col(nil, 0, s.Dx0)), dt)
+	if err != nil {
+		return errors.New("discretization of dx0 failed")
+	}
+	d.Dx0 = mat.NewVecDense(n, mat.Col(nil, 0, dx0))
+	return nil
+}
+
+// checkOperatingPoint checks the dimensions of the operating point
+func checkOperatingPoint(r realization, x0, u0, y0, dx0 *mat.VecDense) error {
+	n, m, p := r.dims()
+	if x0 != nil && x0.Len() != n {
+		return errors.New("x0 should have the length of the state")
+	}
+	if u0 != nil && u0.Len() != m {
+		return errors.New("u0 should have the length of the input")
+	}
+	if y0 != nil && y0.Len() != p {
+		return errors.New("y0 should have the length of the output")
+	}
+	if dx0 != nil && dx0.Len() != n {
+		return errors.New("dx0 should have the length of the state")
+	}
+	return nil
+}
+
+// deviation returns v - v0, or v if v0 is nil
+func deviation(v, v0 *mat.VecDense) *mat.VecDense {
+	if v0 == nil {
+		return v
+	}
+	var dv mat.VecDense
+	dv.SubVec(v, v0)
+	return &dv
+}
+
+// addOffset adds the offset v0 to v in place, if v0 is not nil
+func addOffset(v, v0 *mat.VecDense) *mat.VecDense {
+	if v0 != nil {
+		v.AddVec(v, v0)
+	}
+	return v
+}
+
+// copyVec returns a copy of v or nil
+func copyVec(v *mat.VecDense) *mat.VecDense {
+	if v == nil {
+		return nil
+	}
+	return mat.VecDenseCopyOf(v)
+}
@@ -0,0 +1,54 @@
+package lti
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestOperatingPoint(t *testing.T) {
+	// e' = -e + (u - u0) + dx0 with e = x - x0
+	sys := newTestFirstOrder(1, 1, 0)
+	x0 := mat.NewVecDense(1, []float64{2})
+	u0 := mat.NewVecDense(1, []float64{2})
+	y0 := mat.NewVecDense(1, []float64{5})
+	dx0 := mat.NewVecDense(1, []float64{0.5})
+	if err := sys.SetOperatingPoint(x0, u0, y0, dx0); err != nil {
+		t.Fatal(err)
+	}
+
+	x := mat.NewVecDense(1, []float64{3})
+	u := mat.NewVecDense(1, []float64{2})
+	if dx := sys.DerivativeAbsolute(x, u).AtVec(0); math.Abs(dx+0.5) > 1e-12 {
+		t.Error("DerivativeAbsolute returned wrong derivative:", dx)
+	}
+	if y := sys.ResponseAbsolute(x, u).AtVec(0); math.Abs(y-6) > 1e-12 {
+		t.Error("ResponseAbsolute returned wrong output:", y)
+	}
+
+	dt := 0.1
+	disc, err := sys.Discretize(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 2 + 0.5 + 0.5*math.Exp(-dt)
+	if next := disc.PredictAbsolute(x, u).AtVec(0); math.Abs(next-expected) > 1e-10 {
+		t.Error("PredictAbsolute returned wrong state:", next, "expected:", expected)
+	}
+	if y := disc.ResponseAbsolute(x, u).AtVec(0); math.Abs(y-6) > 1e-12 {
+		t.Error("discrete ResponseAbsolute returned wrong output:", y)
+	}
+
+	// equilibrium without offsets matches Predict
+	if err := disc.SetOperatingPoint(nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(disc.PredictAbsolute(x, u), disc.Predict(x, u), 1e-15) {
+		t.Error("PredictAbsolute without operating point should equal Predict")
+	}
+
+	if err := sys.SetOperatingPoint(mat.NewVecDense(2, nil), nil, nil, nil); err == nil {
+		t.Error("expected error for wrong dimension of x0")
+	}
+}
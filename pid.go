@@ -0,0 +1,265 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//AntiWindup selects how the integrator of a PID is limited while the output saturates
+type AntiWindup int
+
+const (
+	//NoAntiWindup integrates the error regardless of the saturation
+	NoAntiWindup AntiWindup = iota
+	//BackCalculation feeds the saturation error u - v back to the integrator
+	//with the tracking time constant Tt
+	BackCalculation
+	//Clamping stops the integration while the output saturates and the
+	//error drives the output further into saturation
+	Clamping
+)
+
+//PID is a discrete PID controller with setpoint weighting and a first-order
+//derivative filter
+// 	v = Kp (Beta r - y) + Ki Int (r - y) dt + Kd s / (Tf s + 1) (Gamma r - y)
+//and the output u = sat(v) limited to [UMin, UMax]. The integral is discretized
+//by forward and the filtered derivative by backward differences.
+//All states start at zero like the states of Discrete and TwoDOFDiscrete, so
+//that the derivative acts on the change of Gamma r - y from zero at the first
+//sample. A call of Manual with the initial output before the first Update
+//avoids this derivative kick.
+//
+// The parameters are:
+// 	Kp, Ki, Kd:  Proportional, integral and derivative gains
+// 	Tf:          Time constant of the derivative filter
+// 	Beta, Gamma: Setpoint weights of the proportional and derivative terms
+// 	Dt:          Sample time
+// 	UMin, UMax:  Output limits
+// 	AntiWindup:  Anti-windup method
+// 	Tt:          Tracking time constant of the back-calculation (sqrt(Ti Td) or Ti if zero)
+//
+type PID struct {
+	Kp, Ki, Kd  float64
+	Tf          float64
+	Beta, Gamma float64
+	Dt          float64
+	UMin, UMax  float64
+	AntiWindup  AntiWindup
+	Tt          float64
+
+	integral   float64 // Integral term I(k)
+	derivative float64 // Filtered derivative term D(k-1)
+	lastEd     float64 // Derivative error Gamma r - y of the last step
+	lastEp     float64 // Proportional error Beta r - y of the last step
+}
+
+//NewPID returns a PID controller with the gains kp, ki and kd, the derivative
+//filter time constant tf and the sample time dt. The setpoint weights are 1,
+//the output is not limited and back-calculation is used as anti-windup.
+func NewPID(kp, ki, kd, tf, dt float64) (*PID, error) {
+	if dt <= 0 {
+		return nil, errors.New("PID: sample time should be positive")
+	}
+	if kd != 0 && tf <= 0 {
+		return nil, errors.New("PID: derivative filter time constant should be positive")
+	}
+	return &PID{
+		Kp:         kp,
+		Ki:         ki,
+		Kd:         kd,
+		Tf:         tf,
+		Beta:       1,
+		Gamma:      1,
+		Dt:         dt,
+		UMin:       math.Inf(-1),
+		UMax:       math.Inf(1),
+		AntiWindup: BackCalculation,
+	}, nil
+}
+
+//Update returns the output u(k) for the setpoint r(k) and the measurement y(k)
+//and advances the controller by one sample.
+func (c *PID) Update(r, y float64) float64 {
+	ep, e := c.Beta*r-y, r-y
+	d := c.derivativeTerm(r, y)
+	v := c.Kp*ep + c.integral + d
+	u := c.saturate(v)
+
+	switch c.AntiWindup {
+	case BackCalculation:
+		c.integral += c.Ki*c.Dt*e + c.Dt/c.trackingTime()*(u-v)
+	case Clamping:
+		if !(v > c.UMax && c.Ki*e > 0) && !(v < c.UMin && c.Ki*e < 0) {
+			c.integral += c.Ki * c.Dt * e
+		}
+	default:
+		c.integral += c.Ki * c.Dt * e
+	}
+	c.lastEp = ep
+	return u
+}
+
+//Manual applies the manual output u for the setpoint r and the measurement y.
+//The integrator tracks u and the derivative filter is held at zero, so that
+//the next Update continues bumplessly.
+func (c *PID) Manual(u, r, y float64) float64 {
+	ep, ed := c.Beta*r-y, c.Gamma*r-y
+	u = c.saturate(u)
+	c.derivative, c.lastEd = 0, ed
+	c.integral = u - c.Kp*ep
+	c.lastEp = ep
+	return u
+}
+
+//SetGains changes the gains and adjusts the integrator, so that the output
+//does not jump for the errors of the last step.
+func (c *PID) SetGains(kp, ki, kd float64) error {
+	if kd != 0 && c.Tf <= 0 {
+		return errors.New("PID: derivative filter time constant should be positive")
+	}
+	c.integral += (c.Kp - kp) * c.lastEp
+	c.Kp, c.Ki, c.Kd = kp, ki, kd
+	return nil
+}
+
+//Reset clears the states of the controller.
+func (c *PID) Reset() {
+	c.integral, c.derivative, c.lastEd, c.lastEp = 0, 0, 0, 0
+}
+
+//System returns the time-continuous controller
+// 	C(s) = Kp + Ki / s + Kd s / (Tf s + 1)
+//from the error e = r - y to the output u, which can be connected with the
+//plant by Series or Feedback and analyzed with the margin tools.
+//Setpoint weights and output limits are not included.
+func (c *PID) System() (*System, error) {
+	return c.realization(false).system()
+}
+
+//Discrete returns the discrete controller from the error e = r - y to the
+//output u with the difference equations of Update, see System.
+func (c *PID) Discrete() (*Discrete, error) {
+	return c.realization(true).discrete(c.Dt)
+}
+
+//TwoDOFSystem returns the time-continuous controller with the inputs
+//[r; y] and the output u including the setpoint weights.
+func (c *PID) TwoDOFSystem() (*System, error) {
+	r := c.realization(false)
+	return c.twoDOF(r).system()
+}
+
+//TwoDOFDiscrete returns the discrete controller with the inputs [r; y] and
+//the output u including the setpoint weights.
+func (c *PID) TwoDOFDiscrete() (*Discrete, error) {
+	r := c.realization(true)
+	return c.twoDOF(r).discrete(c.Dt)
+}
+
+// derivativeTerm updates the filtered derivative
+// D(k) = Tf / (Tf + dt) D(k-1) + Kd / (Tf + dt) (ed(k) - ed(k-1))
+func (c *PID) derivativeTerm(r, y float64) float64 {
+	ed := c.Gamma*r - y
+	a, g := c.filterCoefficients()
+	c.derivative = a*c.derivative + g*(ed-c.lastEd)
+	c.lastEd = ed
+	return c.derivative
+}
+
+// filterCoefficients returns a = Tf / (Tf + dt) and g = Kd / (Tf + dt)
+func (c *PID) filterCoefficients() (float64, float64) {
+	return c.Tf / (c.Tf + c.Dt), c.Kd / (c.Tf + c.Dt)
+}
+
+// saturate limits v to [UMin, UMax]
+func (c *PID) saturate(v float64) float64 {
+	return math.Min(math.Max(v, c.UMin), c.UMax)
+}
+
+// trackingTime returns the time constant of the back-calculation
+func (c *PID) trackingTime() float64 {
+	if c.Tt > 0 {
+		return c.Tt
+	}
+	if c.Ki == 0 {
+		return math.Inf(1)
+	}
+	// sqrt(Ti Td) = sqrt(Kd / Ki) or Ti = Kp / Ki
+	if c.Kd != 0 {
+		return math.Sqrt(math.Abs(c.Kd / c.Ki))
+	}
+	if c.Kp != 0 {
+		return math.Abs(c.Kp / c.Ki)
+	}
+	return 1 / math.Abs(c.Ki)
+}
+
+// realization returns the controller from the error e to u with the states
+// of the integrator and, for Kd != 0, of the derivative filter.
+//
+// Time-continuous with the filter state xf' = (e - xf) / Tf:
+//  A = [0 0; 0 -1/Tf], B = [1; 1/Tf], C = [Ki -Kd/Tf], D = Kp + Kd/Tf
+// Discrete with xd(k) = a D(k-1) - g e(k-1):
+//  A_d = [1 0; 0 a], B_d = [Ki dt; g (a - 1)], C = [1 1], D = Kp + g
+func (c *PID) realization(discrete bool) realization {
+	n := 1
+	if c.Kd != 0 {
+		n = 2
+	}
+	a := mat.NewDense(n, n, nil)
+	b := mat.NewDense(n, 1, nil)
+	cm := mat.NewDense(1, n, nil)
+	d := mat.NewDense(1, 1, []float64{c.Kp})
+	if discrete {
+		a.Set(0, 0, 1)
+		b.Set(0, 0, c.Ki*c.Dt)
+		cm.Set(0, 0, 1)
+	} else {
+		b.Set(0, 0, 1)
+		cm.Set(0, 0, c.Ki)
+	}
+	if n == 2 {
+		if discrete {
+			fa, g := c.filterCoefficients()
+			a.Set(1, 1, fa)
+			b.Set(1, 0, g*(fa-1))
+			cm.Set(0, 1, 1)
+			d.Set(0, 0, c.Kp+g)
+		} else {
+			a.Set(1, 1, -1/c.Tf)
+			b.Set(1, 0, 1/c.Tf)
+			cm.Set(0, 1, -c.Kd/c.Tf)
+			d.Set(0, 0, c.Kp+c.Kd/c.Tf)
+		}
+	}
+	return realization{
+		a: a, b: b, c: cm, d: d,
+		inputs:  []Signal{{Name: "e"}},
+		outputs: []Signal{{Name: "u"}},
+	}
+}
+
+// twoDOF returns the controller with the inputs [r; y] of the error
+// controller r with the setpoint weights Beta and Gamma of the proportional
+// and derivative terms
+func (c *PID) twoDOF(r realization) realization {
+	n, _, _ := r.dims()
+	b := mat.NewDense(n, 2, nil)
+	b.Set(0, 0, r.b.At(0, 0))
+	b.Set(0, 1, -r.b.At(0, 0))
+
+	// split the feedthrough into the proportional and derivative parts
+	dd := r.d.At(0, 0) - c.Kp
+	if n == 2 {
+		b.Set(1, 0, c.Gamma*r.b.At(1, 0))
+		b.Set(1, 1, -r.b.At(1, 0))
+	}
+	d := mat.NewDense(1, 2, []float64{c.Beta*c.Kp + c.Gamma*dd, -c.Kp - dd})
+	return realization{
+		a: r.a, b: b, c: r.c, d: d,
+		inputs:  []Signal{{Name: "r"}, {Name: "y"}},
+		outputs: []Signal{{Name: "u"}},
+	}
+}
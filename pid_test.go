@@ -0,0 +1,165 @@
+package lti
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPIDDiscrete(t *testing.T) {
+	pid, err := NewPID(2, 1.5, 0.4, 0.05, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid.Beta, pid.Gamma = 0.7, 0.2
+
+	d, err := pid.TwoDOFDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := mat.NewVecDense(2, nil)
+	for k := 0; k < 100; k++ {
+		r, y := 1.0, 0.5+math.Sin(0.1*float64(k))
+		u := pid.Update(r, y)
+		in := mat.NewVecDense(2, []float64{r, y})
+		if v := d.Response(x, in).AtVec(0); math.Abs(u-v) > 1e-12 {
+			t.Fatal("state-space PID differs from Update at step", k, ":", v, u)
+		}
+		x = mat.VecDenseCopyOf(d.Predict(x, in))
+	}
+}
+
+func TestPIDSystem(t *testing.T) {
+	pid, err := NewPID(2, 1.5, 0.4, 0.05, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys, err := pid.System()
+	if err != nil {
+		t.Fatal(err)
+	}
+	omegas := []float64{0.1, 1, 10, 100}
+	resp, err := sys.FrequencyResponse(omegas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range omegas {
+		s := complex(0, w)
+		expected := 2 + 1.5/s + 0.4*s/(0.05*s+1)
+		if cmplx.Abs(resp[i].At(0, 0)-expected) > 1e-9*cmplx.Abs(expected) {
+			t.Error("PID frequency response wrong at", w, ":", resp[i].At(0, 0), expected)
+		}
+	}
+
+	// close the loop with the double integrator
+	plant, err := NewSystem(
+		mat.NewDense(2, 2, []float64{0, 1, 0, 0}),
+		mat.NewDense(2, 1, []float64{0, 1}),
+		mat.NewDense(1, 2, []float64{1, 0}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err = NewPID(4, 1, 3, 0.05, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys, err = pid.System()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loop, err := sys.Series(plant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	margins, err := Margins(loop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if margins.PhaseMargin <= 0 || math.IsInf(margins.PhaseMargin, 1) {
+		t.Error("PID loop should have a positive phase margin, received:", margins.PhaseMargin)
+	}
+	closed, err := plant.Feedback(sys, -1, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poles, err := closed.Poles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range poles {
+		if real(p) >= 0 {
+			t.Error("closed loop with PID should be stable, received pole:", p)
+		}
+	}
+}
+
+func TestPIDAntiWindup(t *testing.T) {
+	// first-order plant y(k+1) = 0.95 y(k) + 0.05 u(k) with saturated input
+	overshoot := func(method AntiWindup) float64 {
+		pid, err := NewPID(3, 2, 0, 0, 0.1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pid.UMin, pid.UMax = -1.2, 1.2
+		pid.AntiWindup = method
+		y, peak := 0.0, 0.0
+		for k := 0; k < 600; k++ {
+			u := pid.Update(1, y)
+			if u > 1.2 || u < -1.2 {
+				t.Fatal("PID output exceeds the limits:", u)
+			}
+			y = 0.95*y + 0.05*u
+			peak = math.Max(peak, y)
+		}
+		if math.Abs(y-1) > 1e-3 {
+			t.Error("PID should settle at the setpoint with method", method, ", received:", y)
+		}
+		return peak - 1
+	}
+	none := overshoot(NoAntiWindup)
+	back := overshoot(BackCalculation)
+	clamp := overshoot(Clamping)
+	if back >= none || clamp >= none {
+		t.Error("anti-windup should reduce the overshoot:", none, back, clamp)
+	}
+}
+
+func TestPIDBumpless(t *testing.T) {
+	pid, err := NewPID(2, 1, 0.5, 0.1, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < 10; k++ {
+		pid.Manual(0.3, 1, 0.8)
+	}
+	if u := pid.Update(1, 0.8); math.Abs(u-0.3) > 1e-12 {
+		t.Error("transfer from manual to automatic should be bumpless, received:", u)
+	}
+
+	before := pid.Update(1, 0.8)
+	if err := pid.SetGains(5, 1, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	after := pid.Update(1, 0.8)
+	if math.Abs(after-before-pid.Ki*pid.Dt*0.2) > 1e-12 {
+		t.Error("gain change should be bumpless:", before, after)
+	}
+
+	// Manual before the first Update avoids the derivative kick
+	pid.Reset()
+	pid.Manual(0.3, 1, 0.4)
+	if u := pid.Update(1, 0.4); math.Abs(u-0.3) > 1e-12 {
+		t.Error("start after Manual should be bumpless, received:", u)
+	}
+
+	if _, err := NewPID(1, 1, 1, 0, 0.01); err == nil {
+		t.Error("expected error for missing derivative filter")
+	}
+	if _, err := NewPID(1, 1, 0, 0, 0); err == nil {
+		t.Error("expected error for zero sample time")
+	}
+}
@@ -0,0 +1,282 @@
+package lti
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// tuningGridSize is the number of integral times used to bracket the phase condition
+const tuningGridSize = 400
+
+//FOPDT is a first-order plus dead-time model
+// 	G(s) = K exp(-L s) / (T s + 1)
+//
+// The fields are:
+// 	Gain:         Static gain K
+// 	TimeConstant: Time constant T
+// 	DeadTime:     Dead time L
+//
+type FOPDT struct {
+	Gain         float64
+	TimeConstant float64
+	DeadTime     float64
+}
+
+//PIDGains contains the gains of a PID controller
+// 	C(s) = Kp + Ki / s + Kd s / (Tf s + 1)
+type PIDGains struct {
+	Kp, Ki, Kd float64
+	Tf         float64
+}
+
+//PIDType selects the structure of a tuned controller
+type PIDType int
+
+const (
+	//PIController has proportional and integral action
+	PIController PIDType = iota
+	//PIDController has proportional, integral and filtered derivative action
+	PIDController
+)
+
+//PID returns a PID controller with the gains and the sample time dt, see NewPID.
+func (g PIDGains) PID(dt float64) (*PID, error) {
+	return NewPID(g.Kp, g.Ki, g.Kd, g.Tf, dt)
+}
+
+//FOPDT fits a first-order plus dead-time model to the stable SISO system by
+//matching the static gain and the first two moments of the impulse response,
+//i.e. the derivatives of log G(s) at s = 0: T^2 = (log G)''(0) and
+//T + L = -(log G)'(0). Input and output delays are added to the dead time.
+func (s *System) FOPDT() (*FOPDT, error) {
+	if err := s.checkDelays(); err != nil {
+		return nil, err
+	}
+	delay := delayAt(s.InputDelay, 0) + delayAt(s.OutputDelay, 0)
+	return fitFOPDT(s.A, s.B, s.C, s.D, 0, delay)
+}
+
+//FOPDT fits a first-order plus dead-time model to the stable SISO discrete
+//system with G(s) = G_d(exp(s Dt)), see System.FOPDT. The dead time includes
+//the half sample of the zero-order hold.
+func (d *Discrete) FOPDT() (*FOPDT, error) {
+	if d.Dt <= 0 {
+		return nil, errors.New("sample time Dt should be positive")
+	}
+	return fitFOPDT(d.Ad, d.Bd, d.C, d.D, d.Dt, 0)
+}
+
+//SIMC returns the PI gains of the SIMC rule of Skogestad
+// 	Kp = T / (K (tc + L)), Ti = min(T, 4 (tc + L))
+//with the closed-loop time constant tc; tc = L is used if tc <= 0.
+//For Ti = T the integral gain is Ki = 1 / (K (tc + L)), which gives the pure
+//integral controller Kp = 0 for T = 0.
+func (m *FOPDT) SIMC(tc float64) (*PIDGains, error) {
+	if tc <= 0 {
+		tc = m.DeadTime
+	}
+	if tc <= 0 {
+		return nil, errors.New("SIMC: closed-loop time constant should be positive")
+	}
+	if m.Gain == 0 {
+		return nil, errors.New("SIMC: gain should not be zero")
+	}
+	if m.TimeConstant < 0 {
+		return nil, errors.New("SIMC: time constant should not be negative")
+	}
+	kp := m.TimeConstant / (m.Gain * (tc + m.DeadTime))
+	ki := 1 / (m.Gain * (tc + m.DeadTime))
+	if m.TimeConstant > 4*(tc+m.DeadTime) {
+		ki = kp / (4 * (tc + m.DeadTime))
+	}
+	return &PIDGains{Kp: kp, Ki: ki}, nil
+}
+
+//IMC returns the PID gains of the internal model control rule with a
+//first-order Pade approximation of the dead time and the closed-loop time
+//constant lambda
+// 	Kp = (2 T + L) / (K (2 lambda + L)), Ti = T + L / 2, Td = T L / (2 T + L)
+//The derivative filter time constant is Td / 10.
+func (m *FOPDT) IMC(lambda float64) (*PIDGains, error) {
+	if lambda <= 0 {
+		return nil, errors.New("IMC: closed-loop time constant should be positive")
+	}
+	if m.Gain == 0 {
+		return nil, errors.New("IMC: gain should not be zero")
+	}
+	t, l := m.TimeConstant, m.DeadTime
+	if t+l/2 <= 0 {
+		return nil, errors.New("IMC: time constant and dead time should not both be zero")
+	}
+	kp := (2*t + l) / (m.Gain * (2*lambda + l))
+	ti := t + l/2
+	td := t * l / (2*t + l)
+	return pidGains(kp, ti, td), nil
+}
+
+//ZieglerNichols returns the gains of the Ziegler-Nichols frequency response
+//rules for the ultimate gain Ku and the ultimate period Pu of the SISO plant,
+//which are the smallest gain margin over all phase crossovers w > 0 and the
+//period of its phase crossover:
+// 	PI:  Kp = 0.45 Ku, Ti = Pu / 1.2
+// 	PID: Kp = 0.6 Ku,  Ti = Pu / 2, Td = Pu / 8
+func ZieglerNichols(plant FrequencyResponder, typ PIDType) (*PIDGains, error) {
+	e, err := evaluatorOf(plant)
+	if err != nil {
+		return nil, err
+	}
+	if e.p != 1 || e.m != 1 {
+		return nil, errors.New("ZieglerNichols: plant should be SISO")
+	}
+	grid, err := plant.FrequencyGrid(marginGridSize)
+	if err != nil {
+		return nil, err
+	}
+	l := func(w float64) complex128 {
+		v, err := e.at(e.point(w), 0, 0)
+		if err != nil {
+			return cmplx.Inf()
+		}
+		return v
+	}
+
+	ku, wu := math.Inf(1), 0.0
+	freqs, gms := phaseCrossovers(l, grid, e.dt)
+	for i, gm := range gms {
+		if gm < ku && freqs[i] > 0 {
+			ku, wu = gm, freqs[i]
+		}
+	}
+	if math.IsInf(ku, 1) {
+		return nil, errors.New("ZieglerNichols: plant has no finite ultimate gain")
+	}
+	pu := 2 * math.Pi / wu
+	if typ == PIController {
+		return pidGains(0.45*ku, pu/1.2, 0), nil
+	}
+	return pidGains(0.6*ku, pu/2, pu/8), nil
+}
+
+//TunePhaseMargin returns the PI or PID gains for which the open loop with the
+//SISO plant has the gain crossover frequency wc (rad/s) and the phase margin
+//pm (degrees). The PID uses Td = Ti / 4. The integral time is found by
+//root-finding on the phase condition; if several integral times satisfy it,
+//the largest is used. The proportional gain follows from |L(j wc)| = 1.
+func TunePhaseMargin(plant FrequencyResponder, pm, wc float64, typ PIDType) (*PIDGains, error) {
+	if wc <= 0 {
+		return nil, errors.New("TunePhaseMargin: crossover frequency should be positive")
+	}
+	e, err := evaluatorOf(plant)
+	if err != nil {
+		return nil, err
+	}
+	if e.p != 1 || e.m != 1 {
+		return nil, errors.New("TunePhaseMargin: plant should be SISO")
+	}
+	g, err := e.at(e.point(wc), 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if g == 0 {
+		return nil, errors.New("TunePhaseMargin: plant has no gain at the crossover frequency")
+	}
+
+	// required phase of the controller: arg C = -180 + pm - arg G
+	target := -math.Pi + pm*math.Pi/180 - cmplx.Phase(g)
+	target = wrapDegrees(target*180/math.Pi) * math.Pi / 180
+
+	tdRatio := 0.0
+	if typ == PIDController {
+		tdRatio = 0.25
+	}
+	shape := func(ti float64) complex128 {
+		gains := pidGains(1, ti, tdRatio*ti)
+		s := complex(0, wc)
+		return complex(gains.Kp, 0) + complex(gains.Ki, 0)/s + complex(gains.Kd, 0)*s/(complex(gains.Tf, 0)*s+1)
+	}
+	phase := func(ti float64) float64 {
+		return cmplx.Phase(shape(ti)) - target
+	}
+
+	grid := make([]float64, tuningGridSize)
+	for k := range grid {
+		grid[k] = math.Pow(10, -3+6*float64(k)/float64(tuningGridSize-1)) / wc
+	}
+	roots := crossings(phase, grid)
+	if len(roots) == 0 {
+		return nil, errors.New("TunePhaseMargin: phase margin is not achievable at the crossover frequency")
+	}
+	ti := roots[len(roots)-1]
+
+	kp := 1 / cmplx.Abs(g*shape(ti))
+	return pidGains(kp, ti, tdRatio*ti), nil
+}
+
+// pidGains returns the gains of the controller Kp (1 + 1 / (Ti s) + Td s / (Tf s + 1))
+// with the derivative filter time constant Tf = Td / 10
+func pidGains(kp, ti, td float64) *PIDGains {
+	return &PIDGains{Kp: kp, Ki: kp / ti, Kd: kp * td, Tf: td / 10}
+}
+
+// fitFOPDT fits a FOPDT model by the moments of the stable SISO system with
+// the sample time dt (0 for time-continuous systems) and the additional delay
+func fitFOPDT(a, b, c, d *mat.Dense, dt, delay float64) (*FOPDT, error) {
+	n, m := b.Dims()
+	p, _ := c.Dims()
+	if m != 1 || p != 1 {
+		return nil, errors.New("FOPDT: system should be SISO")
+	}
+	ev, err := eigenvalues(a)
+	if err != nil {
+		return nil, err
+	}
+	if (dt == 0 && !stableContinuous(ev)) || (dt > 0 && !stableDiscrete(ev)) {
+		return nil, errors.New("FOPDT: system should be stable")
+	}
+
+	// M = -A^-1 for continuous and (I - A_d)^-1 for discrete systems
+	var mi, minv mat.Dense
+	mi.Scale(-1, a)
+	if dt > 0 {
+		for i := 0; i < n; i++ {
+			mi.Set(i, i, mi.At(i, i)+1)
+		}
+	}
+	if err := minv.Inverse(&mi); err != nil {
+		return nil, ErrIntegrating
+	}
+
+	// moments C M^k B
+	moment := make([]float64, 4)
+	moment[0] = d.At(0, 0)
+	v := mat.VecDenseCopyOf(b.ColView(0))
+	for k := 1; k < 4; k++ {
+		v.MulVec(&minv, v)
+		moment[k] = mat.Dot(c.RowView(0), v)
+	}
+
+	// G(0), G'(0) and G''(0); for discrete systems G(s) = H(exp(s Dt)) with
+	// H(1) = D + C M B, H'(1) = -C M^2 B and H''(1) = 2 C M^3 B
+	g0 := moment[0] + moment[1]
+	g1, g2 := -moment[2], 2*moment[3]
+	if dt > 0 {
+		g2 = dt * dt * (g2 + g1)
+		g1 = dt * g1
+	}
+	if g0 == 0 {
+		return nil, errors.New("FOPDT: static gain should not be zero")
+	}
+
+	// (log G)'(0) = -(T + L), (log G)''(0) = T^2
+	l1 := g1 / g0
+	l2 := g2/g0 - l1*l1
+	t := math.Sqrt(math.Max(l2, 0))
+	l := -l1 - t
+	if l < 0 {
+		t, l = math.Max(-l1, 0), 0
+	}
+	return &FOPDT{Gain: g0, TimeConstant: t, DeadTime: l + delay}, nil
+}
@@ -0,0 +1,233 @@
+package lti
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// newTestLag returns 1/(s+1)^3
+func newTestLag() (*System, error) {
+	return NewSystem(
+		mat.NewDense(3, 3, []float64{-1, 1, 0, 0, -1, 1, 0, 0, -1}),
+		mat.NewDense(3, 1, []float64{0, 0, 1}),
+		mat.NewDense(1, 3, []float64{1, 0, 0}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+}
+
+func TestFOPDT(t *testing.T) {
+	// 2 exp(-0.5 s) / (3 s + 1)
+	sys, err := NewSystem(
+		mat.NewDense(1, 1, []float64{-1.0 / 3}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{2.0 / 3}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys.InputDelay = []float64{0.5}
+	model, err := sys.FOPDT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(model.Gain-2) > 1e-9 || math.Abs(model.TimeConstant-3) > 1e-9 || math.Abs(model.DeadTime-0.5) > 1e-9 {
+		t.Error("FOPDT fit wrong, received:", *model)
+	}
+
+	sys.InputDelay = nil
+	disc, err := sys.Discretize(0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err = disc.FOPDT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(model.Gain-2) > 1e-9 || math.Abs(model.TimeConstant-3) > 1e-3 || math.Abs(model.DeadTime-0.005) > 1e-3 {
+		t.Error("discrete FOPDT fit wrong, received:", *model)
+	}
+
+	// 1/(s+1)^3 has T + L = 3 and T^2 = 3
+	lag, err := newTestLag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err = lag.FOPDT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(model.TimeConstant-math.Sqrt(3)) > 1e-9 || math.Abs(model.DeadTime-3+math.Sqrt(3)) > 1e-9 {
+		t.Error("FOPDT fit of the lag wrong, received:", *model)
+	}
+
+	unstable, err := NewSystem(
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unstable.FOPDT(); err == nil {
+		t.Error("expected error for unstable system")
+	}
+}
+
+func TestFOPDTRules(t *testing.T) {
+	model := &FOPDT{Gain: 2, TimeConstant: 10, DeadTime: 1}
+	gains, err := model.SIMC(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kp = 10 / (2 * 2), Ti = min(10, 8)
+	if math.Abs(gains.Kp-2.5) > 1e-12 || math.Abs(gains.Ki-2.5/8) > 1e-12 || gains.Kd != 0 {
+		t.Error("SIMC gains wrong, received:", *gains)
+	}
+
+	gains, err = model.IMC(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kp = 21 / (2 * 5), Ti = 10.5, Td = 10 / 21
+	kp := 21.0 / 10
+	if math.Abs(gains.Kp-kp) > 1e-12 || math.Abs(gains.Ki-kp/10.5) > 1e-12 ||
+		math.Abs(gains.Kd-kp*10/21) > 1e-12 || math.Abs(gains.Tf-1.0/21) > 1e-12 {
+		t.Error("IMC gains wrong, received:", *gains)
+	}
+
+	if _, err := model.IMC(0); err == nil {
+		t.Error("expected error for zero closed-loop time constant")
+	}
+	if _, err := (&FOPDT{Gain: 2}).IMC(1); err == nil {
+		t.Error("expected error for zero time constant and dead time")
+	}
+	if _, err := (&FOPDT{Gain: 1, TimeConstant: 1}).SIMC(0); err == nil {
+		t.Error("expected error for zero dead time and time constant")
+	}
+	if _, err := (&FOPDT{TimeConstant: 1, DeadTime: 1}).SIMC(1); err == nil {
+		t.Error("expected error for zero gain")
+	}
+
+	// pure dead time gives an integral controller Ki = 1 / (2 * 2)
+	gains, err = (&FOPDT{Gain: 2, DeadTime: 1}).SIMC(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gains.Kp != 0 || math.Abs(gains.Ki-0.25) > 1e-12 {
+		t.Error("SIMC gains for pure dead time wrong, received:", *gains)
+	}
+}
+
+func TestZieglerNichols(t *testing.T) {
+	// 1/(s+1)^3 has Ku = 8 at w = sqrt(3)
+	plant, err := newTestLag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pu := 2 * math.Pi / math.Sqrt(3)
+	gains, err := ZieglerNichols(plant, PIDController)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(gains.Kp-4.8) > 1e-6 || math.Abs(gains.Ki-4.8/(pu/2)) > 1e-6 || math.Abs(gains.Kd-4.8*pu/8) > 1e-6 {
+		t.Error("Ziegler-Nichols PID gains wrong, received:", *gains)
+	}
+	gains, err = ZieglerNichols(plant, PIController)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(gains.Kp-3.6) > 1e-6 || math.Abs(gains.Ki-3.6*1.2/pu) > 1e-6 || gains.Kd != 0 {
+		t.Error("Ziegler-Nichols PI gains wrong, received:", *gains)
+	}
+
+	first, err := NewSystem(
+		mat.NewDense(1, 1, []float64{-1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ZieglerNichols(first, PIController); err == nil {
+		t.Error("expected error for plant without phase crossover")
+	}
+
+	// 3 exp(-s) / (s + 1) has the gain margins 0.75 at the first phase
+	// crossover atan(w) + w = pi, w = 2.03, and 2.68 at the second one
+	first.C.Set(0, 0, 3)
+	first.InputDelay = []float64{1}
+	wu := 2.0
+	for i := 0; i < 20; i++ {
+		wu -= (math.Atan(wu) + wu - math.Pi) / (1/(1+wu*wu) + 1)
+	}
+	ku := math.Sqrt(1+wu*wu) / 3
+	if math.Abs(ku-0.754) > 1e-3 || math.Abs(wu-2.029) > 1e-3 {
+		t.Fatal("wrong reference ultimate gain:", ku, wu)
+	}
+	gains, err = ZieglerNichols(first, PIController)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(gains.Kp-0.45*ku) > 1e-6 || math.Abs(gains.Ki-0.45*ku*1.2*wu/(2*math.Pi)) > 1e-6 {
+		t.Error("Ziegler-Nichols should use the smallest gain margin, received:", *gains, ku, wu)
+	}
+
+	// 100 / (s + 1)^3 has Ku = 8 / 100 below 1
+	high, err := newTestLag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	high.C.Scale(100, high.C)
+	gains, err = ZieglerNichols(high, PIDController)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(gains.Kp-0.6*0.08) > 1e-6 || math.Abs(gains.Ki-0.6*0.08/(pu/2)) > 1e-6 {
+		t.Error("Ziegler-Nichols gains wrong for an ultimate gain below 1, received:", *gains)
+	}
+}
+
+func TestTunePhaseMargin(t *testing.T) {
+	plant, err := newTestLag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range []PIDType{PIController, PIDController} {
+		gains, err := TunePhaseMargin(plant, 50, 0.4, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pid, err := gains.PID(0.01)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := pid.System()
+		if err != nil {
+			t.Fatal(err)
+		}
+		loop, err := c.Series(plant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		margins, err := Margins(loop)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(margins.PhaseMargin-50) > 1e-3 || math.Abs(margins.GainCrossover-0.4) > 1e-4 {
+			t.Error("tuned loop misses the specification for type", typ, ", received:", margins.PhaseMargin, margins.GainCrossover)
+		}
+	}
+
+	if _, err := TunePhaseMargin(plant, 50, 0, PIController); err == nil {
+		t.Error("expected error for zero crossover frequency")
+	}
+	// the PI controller cannot add phase
+	if _, err := TunePhaseMargin(plant, 60, 1.5, PIController); err == nil {
+		t.Error("expected error for unachievable phase margin")
+	}
+}
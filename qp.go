@@ -0,0 +1,334 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//ErrNotConverged is returned when an iterative solver stops at its iteration
+//limit before reaching the tolerance; the last iterate is still returned.
+var ErrNotConverged = errors.New("solver did not converge")
+
+//QPMethod selects the algorithm of the QPSolver
+type QPMethod int
+
+const (
+	//ADMM is the operator splitting method of OSQP with Ruiz equilibration,
+	//adaptive step size and polishing of the active set. It requires a
+	//positive semidefinite Hessian and is warm started by the last solution.
+	ADMM QPMethod = iota
+	//ActiveSet is the dual active-set method of Goldfarb and Idnani, which
+	//solves the problem exactly in a finite number of steps. It requires a
+	//positive definite Hessian and is warm started by the last active set.
+	ActiveSet
+)
+
+//QPOptions contains the settings of the QPSolver. Zero values select
+//the defaults of the method.
+//
+// The fields are:
+// 	Method:        Algorithm of the solver (default ADMM)
+// 	MaxIterations: Iteration limit per solve (default 10000 for ADMM, 10 * (n + rows) for ActiveSet)
+// 	Tolerance:     Relative tolerance of the residuals (default 1e-6 for ADMM, 1e-9 for ActiveSet)
+//
+type QPOptions struct {
+	Method        QPMethod
+	MaxIterations int
+	Tolerance     float64
+}
+
+//QPBounds contains the bounds Lower <= A x <= Upper of the general
+//constraints and XMin <= x <= XMax of the variables. Nil bounds are not
+//constrained, single bounds can be left unconstrained with -Inf or +Inf.
+//Equal lower and upper bounds give equality constraints.
+type QPBounds struct {
+	Lower, Upper []float64
+	XMin, XMax   []float64
+}
+
+//QPSolver solves the dense convex quadratic program
+// 	min 1/2 x^T H x + f^T x  s.t.  Lower <= A x <= Upper, XMin <= x <= XMax
+//for fixed H and A, which are factorized once, and changing f and bounds,
+//as they arise in every step of a receding horizon controller.
+//The identity rows of the variable bounds are only added to the constraints
+//once a solve has XMin or XMax.
+type QPSolver struct {
+	H       *mat.SymDense
+	A       *mat.Dense // may be nil for box constraints only
+	Options QPOptions
+
+	method qpMethod // nil without constraints
+	free   quadraticMinimizer
+	box    bool // Identity rows of the variable bounds are stacked below A
+	start  *mat.VecDense
+	l, u   []float64
+}
+
+// qpMethod is the interface of the QP algorithms, which solve the problem
+// l <= A x <= u with the stacked constraints A or [A; I]
+type qpMethod interface {
+	solve(f *mat.VecDense, l, u []float64) (*mat.VecDense, error)
+	warmStart(x *mat.VecDense)
+	iterationCount() int
+}
+
+func (q *admmQP) iterationCount() int      { return q.iterations }
+func (q *activeSetQP) iterationCount() int { return q.iterations }
+
+//NewQPSolver returns a solver for the problem with the Hessian H and the
+//constraint matrix A.
+func NewQPSolver(h *mat.SymDense, a *mat.Dense, opts QPOptions) (*QPSolver, error) {
+	n := h.Symmetric()
+	rows := 0
+	if a != nil {
+		var cols int
+		rows, cols = a.Dims()
+		if cols != n {
+			return nil, errors.New("QP: columns of A should match the size of H")
+		}
+	}
+
+	s := &QPSolver{
+		H:       h,
+		A:       a,
+		Options: opts,
+		free:    quadraticMinimizer{h: h},
+	}
+	switch opts.Method {
+	case ADMM:
+		if s.Options.MaxIterations == 0 {
+			s.Options.MaxIterations = 10000
+		}
+		if s.Options.Tolerance == 0 {
+			s.Options.Tolerance = 1e-6
+		}
+	case ActiveSet:
+		if s.Options.MaxIterations == 0 {
+			s.Options.MaxIterations = 10 * (n + rows)
+		}
+		if s.Options.Tolerance == 0 {
+			s.Options.Tolerance = 1e-9
+		}
+	default:
+		return nil, errors.New("QP: unknown method")
+	}
+	if err := s.build(false); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//Solve solves the problem for the linear term f and the bounds b and returns
+//the solution x. If the iteration limit is reached, the last iterate is
+//returned together with ErrNotConverged.
+func (s *QPSolver) Solve(f *mat.VecDense, b QPBounds) (*mat.VecDense, error) {
+	n := s.H.Symmetric()
+	if f.Len() != n {
+		return nil, errors.New("QP: f should match the size of H")
+	}
+	if (b.XMin != nil || b.XMax != nil) && !s.box {
+		if err := s.build(true); err != nil {
+			return nil, err
+		}
+	}
+	rows := len(s.l)
+	if s.box {
+		rows -= n
+	}
+	if err := setBounds(s.l[:rows], s.u[:rows], b.Lower, b.Upper); err != nil {
+		return nil, err
+	}
+	if s.box {
+		if err := setBounds(s.l[rows:], s.u[rows:], b.XMin, b.XMax); err != nil {
+			return nil, err
+		}
+	}
+	for i := range s.l {
+		if s.l[i] > s.u[i] {
+			return nil, errors.New("QP: lower bounds should not exceed the upper bounds")
+		}
+	}
+
+	if s.method == nil {
+		x := mat.NewVecDense(n, nil)
+		if err := s.free.minimize(f, x); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+	x, err := s.method.solve(f, s.l, s.u)
+	if x == nil {
+		return nil, err
+	}
+	return mat.VecDenseCopyOf(x), err
+}
+
+// build creates the method for the constraints A and, if box is set, the
+// identity rows of the variable bounds
+func (s *QPSolver) build(box bool) error {
+	n := s.H.Symmetric()
+	rows := 0
+	if s.A != nil {
+		rows, _ = s.A.Dims()
+	}
+	total := rows
+	if box {
+		total += n
+	}
+	s.box = box
+	s.l, s.u = make([]float64, total), make([]float64, total)
+	if total == 0 {
+		s.method = nil
+		if s.Options.Method == ActiveSet {
+			var chol mat.Cholesky
+			if ok := chol.Factorize(s.H); !ok {
+				return errors.New("QP: Hessian should be positive definite for the active-set method")
+			}
+		}
+		return nil
+	}
+
+	stacked := mat.NewDense(total, n, nil)
+	if s.A != nil {
+		setBlock(stacked, 0, 0, s.A)
+	}
+	if box {
+		setIdentity(stacked, rows, 0, n)
+	}
+	switch s.Options.Method {
+	case ADMM:
+		s.method = newADMMQP(s.H, stacked, s.Options.MaxIterations, s.Options.Tolerance)
+	case ActiveSet:
+		m, err := newActiveSetQP(s.H, stacked, s.Options.MaxIterations, s.Options.Tolerance)
+		if err != nil {
+			return err
+		}
+		s.method = m
+	}
+	if s.start != nil {
+		s.method.warmStart(s.start)
+	}
+	return nil
+}
+
+// setProblem replaces H and A by matrices of the same sizes and factorizes
+// them again. The active set or the iterate of the last solve is kept to warm
+// start the next solve.
+func (s *QPSolver) setProblem(h *mat.SymDense, a *mat.Dense) error {
+	old := s.method
+	s.H, s.A = h, a
+	s.free = quadraticMinimizer{h: h}
+	if err := s.build(s.box); err != nil {
+		return err
+	}
+	switch m := s.method.(type) {
+	case *activeSetQP:
+		if o, ok := old.(*activeSetQP); ok {
+			m.previous = o.previous
+		}
+	case *admmQP:
+		if o, ok := old.(*admmQP); ok {
+			m.warmStart(o.x)
+		}
+	}
+	return nil
+}
+
+//WarmStart sets the initial iterate of the next solve. The ADMM method
+//starts from x, the active-set method starts from the last active set.
+func (s *QPSolver) WarmStart(x *mat.VecDense) {
+	s.start = mat.VecDenseCopyOf(x)
+	if s.method != nil {
+		s.method.warmStart(x)
+	}
+}
+
+//Iterations returns the number of iterations of the last solve.
+func (s *QPSolver) Iterations() int {
+	if s.method == nil {
+		return 0
+	}
+	return s.method.iterationCount()
+}
+
+// setBounds copies the bounds into l and u; nil bounds are infinite
+func setBounds(l, u, lower, upper []float64) error {
+	if (lower != nil && len(lower) != len(l)) || (upper != nil && len(upper) != len(u)) {
+		return errors.New("QP: bounds should have the size of the constraints")
+	}
+	for i := range l {
+		l[i], u[i] = math.Inf(-1), math.Inf(1)
+		if lower != nil {
+			l[i] = lower[i]
+		}
+		if upper != nil {
+			u[i] = upper[i]
+		}
+	}
+	return nil
+}
+
+// quadraticMinimizer minimizes 1/2 x^T H x + f^T x without constraints.
+// H is factorized at the first use by a Cholesky decomposition or, if H is
+// only positive semidefinite, by an eigen decomposition.
+type quadraticMinimizer struct {
+	h       *mat.SymDense
+	chol    *mat.Cholesky
+	values  []float64
+	vectors *mat.Dense
+}
+
+// minimize stores the minimizer in x. For a singular H the minimizer with the
+// smallest norm is used, which exists if f is in the range of H.
+func (m *quadraticMinimizer) minimize(f, x *mat.VecDense) error {
+	if m.chol == nil && m.vectors == nil {
+		if err := m.factorize(); err != nil {
+			return err
+		}
+	}
+	if m.chol != nil {
+		var negf mat.VecDense
+		negf.ScaleVec(-1, f)
+		return m.chol.SolveVecTo(x, &negf)
+	}
+
+	// x = -V diag(1 / lambda) V^T f on the range of H
+	var vtf mat.VecDense
+	vtf.MulVec(m.vectors.T(), f)
+	tol := 1e-12 * math.Max(1, math.Abs(m.values[len(m.values)-1]))
+	scale := 1e-9 * math.Max(1, mat.Norm(f, math.Inf(1)))
+	for i, l := range m.values {
+		if l > tol {
+			vtf.SetVec(i, -vtf.AtVec(i)/l)
+		} else if math.Abs(vtf.AtVec(i)) > scale {
+			return errors.New("QP: unconstrained problem is unbounded")
+		} else {
+			vtf.SetVec(i, 0)
+		}
+	}
+	x.MulVec(m.vectors, &vtf)
+	return nil
+}
+
+// factorize factorizes H
+func (m *quadraticMinimizer) factorize() error {
+	var chol mat.Cholesky
+	if ok := chol.Factorize(m.h); ok {
+		m.chol = &chol
+		return nil
+	}
+	var eig mat.EigenSym
+	if ok := eig.Factorize(m.h, true); !ok {
+		return errors.New("QP: eigen decomposition of the Hessian failed")
+	}
+	values := eig.Values(nil)
+	if values[0] < -1e-12*math.Max(1, math.Abs(values[len(values)-1])) {
+		return errors.New("QP: Hessian should be positive semidefinite")
+	}
+	var v mat.Dense
+	eig.VectorsTo(&v)
+	m.values, m.vectors = values, &v
+	return nil
+}
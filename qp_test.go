@@ -0,0 +1,179 @@
+package lti
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestQPSolver(t *testing.T) {
+	// min 1/2 x^T x - 5 x2  s.t.  -4 x1 - 3 x2 >= -8, 2 x1 + x2 >= 2, -2 x2 + x3 >= 0
+	h := mat.NewSymDense(3, []float64{1, 0, 0, 0, 1, 0, 0, 0, 1})
+	f := mat.NewVecDense(3, []float64{0, -5, 0})
+	a := mat.NewDense(3, 3, []float64{
+		-4, -3, 0,
+		2, 1, 0,
+		0, -2, 1,
+	})
+	inf := math.Inf(1)
+	bounds := QPBounds{Lower: []float64{-8, 2, 0}, Upper: []float64{inf, inf, inf}}
+	expected := mat.NewVecDense(3, []float64{0.4761905, 1.0476190, 2.0952381})
+
+	for _, method := range []QPMethod{ADMM, ActiveSet} {
+		s, err := NewQPSolver(h, a, QPOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, err := s.Solve(f, bounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mat.EqualApprox(x, expected, 1e-5) {
+			t.Error("QP method", method, "returned wrong solution:", x.RawVector().Data)
+		}
+		if s.Iterations() == 0 {
+			t.Error("QP method", method, "should iterate")
+		}
+	}
+}
+
+func TestQPSolverBoxAndEquality(t *testing.T) {
+	// min (x1 - 2)^2 + x2^2  s.t.  x1 + x2 = 1, 0 <= x <= 0.8
+	h := mat.NewSymDense(2, []float64{2, 0, 0, 2})
+	f := mat.NewVecDense(2, []float64{-4, 0})
+	a := mat.NewDense(1, 2, []float64{1, 1})
+	bounds := QPBounds{
+		Lower: []float64{1},
+		Upper: []float64{1},
+		XMin:  []float64{0, 0},
+		XMax:  []float64{0.8, 0.8},
+	}
+	expected := mat.NewVecDense(2, []float64{0.8, 0.2})
+
+	for _, method := range []QPMethod{ADMM, ActiveSet} {
+		s, err := NewQPSolver(h, a, QPOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, err := s.Solve(f, bounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mat.EqualApprox(x, expected, 1e-5) {
+			t.Error("QP method", method, "returned wrong solution:", x.RawVector().Data)
+		}
+
+		// the warm started solve of the same problem is not slower
+		first := s.Iterations()
+		s.WarmStart(x)
+		if _, err := s.Solve(f, bounds); err != nil {
+			t.Fatal(err)
+		}
+		if s.Iterations() > first {
+			t.Error("QP method", method, "should not need more iterations after warm start:", s.Iterations(), first)
+		}
+	}
+
+	// box constraints only
+	s, err := NewQPSolver(h, nil, QPOptions{Method: ActiveSet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := s.Solve(f, QPBounds{XMax: []float64{1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(x, mat.NewVecDense(2, []float64{1, 0}), 1e-9) {
+		t.Error("QP returned wrong solution for box constraints:", x.RawVector().Data)
+	}
+}
+
+func TestQPSolverErrors(t *testing.T) {
+	h := mat.NewSymDense(2, []float64{1, 0, 0, 1})
+	f := mat.NewVecDense(2, []float64{-1, -1})
+	a := mat.NewDense(2, 2, []float64{1, 0, 1, 0})
+
+	// infeasible constraints x1 >= 1 and x1 <= 0
+	inf := math.Inf(1)
+	infeasible := QPBounds{Lower: []float64{1, -inf}, Upper: []float64{inf, 0}}
+	s, err := NewQPSolver(h, a, QPOptions{Method: ActiveSet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Solve(f, infeasible); err == nil {
+		t.Error("expected error for infeasible constraints")
+	}
+
+	// iteration limit
+	s, err = NewQPSolver(h, a, QPOptions{MaxIterations: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := s.Solve(f, QPBounds{Upper: []float64{0, 0}})
+	if err != ErrNotConverged || x == nil {
+		t.Error("expected the last iterate and ErrNotConverged at the iteration limit, received:", err)
+	}
+
+	if _, err := NewQPSolver(h, mat.NewDense(1, 3, nil), QPOptions{}); err == nil {
+		t.Error("expected error for wrong size of A")
+	}
+	if _, err := NewQPSolver(mat.NewSymDense(2, nil), nil, QPOptions{Method: ActiveSet}); err == nil {
+		t.Error("expected error for singular Hessian with active-set method")
+	}
+	if _, err := s.Solve(f, QPBounds{Lower: []float64{1}}); err == nil {
+		t.Error("expected error for wrong number of bounds")
+	}
+}
+
+func TestQPSolverEqualityFirst(t *testing.T) {
+	// min (x1 - 1)^2 + (x2 - 1)^2  s.t.  x1 + x2 = 2, x1 <= 0.5
+	h := mat.NewSymDense(2, []float64{2, 0, 0, 2})
+	f := mat.NewVecDense(2, []float64{-2, -2})
+	a := mat.NewDense(2, 2, []float64{1, 1, 1, 0})
+	inf := math.Inf(1)
+	bounds := QPBounds{Lower: []float64{2, -inf}, Upper: []float64{2, 0.5}}
+	expected := mat.NewVecDense(2, []float64{0.5, 1.5})
+
+	for _, method := range []QPMethod{ADMM, ActiveSet} {
+		s, err := NewQPSolver(h, a, QPOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, err := s.Solve(f, bounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mat.EqualApprox(x, expected, 1e-5) {
+			t.Error("QP method", method, "returned wrong solution:", x.RawVector().Data)
+		}
+	}
+}
+
+func TestQPSolverSemidefinite(t *testing.T) {
+	// min 1/2 x1^2 - x1 with the free variable x2
+	h := mat.NewSymDense(2, []float64{1, 0, 0, 0})
+	s, err := NewQPSolver(h, nil, QPOptions{Method: ADMM})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := s.Solve(mat.NewVecDense(2, []float64{-1, 0}), QPBounds{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(x, mat.NewVecDense(2, []float64{1, 0}), 1e-9) {
+		t.Error("QP returned wrong solution for semidefinite Hessian:", x.RawVector().Data)
+	}
+	if _, err := s.Solve(mat.NewVecDense(2, []float64{-1, 1}), QPBounds{}); err == nil {
+		t.Error("expected error for unbounded problem")
+	}
+
+	// the box rows are added by the first solve with variable bounds
+	x, err = s.Solve(mat.NewVecDense(2, []float64{-1, 1}), QPBounds{XMin: []float64{-2, -2}, XMax: []float64{2, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(x, mat.NewVecDense(2, []float64{1, -2}), 1e-4) {
+		t.Error("QP returned wrong solution with box constraints:", x.RawVector().Data)
+	}
+}
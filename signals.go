@@ -0,0 +1,296 @@
+package lti
+
+import (
+	"errors"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//Signal describes an input, output or state channel of a system
+type Signal struct {
+	Name string
+	Unit string
+}
+
+//Sum describes a summing junction Output = Sum_i Signs[i] * Inputs[i]
+//of named signals for Connect. If Signs is nil, all inputs are added.
+type Sum struct {
+	Output string
+	Inputs []string
+	Signs  []float64
+}
+
+//InputIndex returns the index of the input with the given name
+func (s *System) InputIndex(name string) (int, error) {
+	return signalIndex(s.Inputs, name)
+}
+
+//OutputIndex returns the index of the output with the given name
+func (s *System) OutputIndex(name string) (int, error) {
+	return signalIndex(s.Outputs, name)
+}
+
+//StateIndex returns the index of the state with the given name
+func (s *System) StateIndex(name string) (int, error) {
+	return signalIndex(s.States, name)
+}
+
+//InputIndex returns the index of the input with the given name
+func (d *Discrete) InputIndex(name string) (int, error) {
+	return signalIndex(d.Inputs, name)
+}
+
+//OutputIndex returns the index of the output with the given name
+func (d *Discrete) OutputIndex(name string) (int, error) {
+	return signalIndex(d.Outputs, name)
+}
+
+//StateIndex returns the index of the state with the given name
+func (d *Discrete) StateIndex(name string) (int, error) {
+	return signalIndex(d.States, name)
+}
+
+//Connect wires the blocks into a single system by matching signal names.
+//Every block input is driven by the block output, the external input or
+//the summing junction with the same name. The connected system has the
+//external inputs and the outputs given by name; outputs may be any block
+//output, summing junction or external input.
+//All block inputs must be named and connected. Blocks with delays return
+//ErrDelays, see Pade.
+func Connect(blocks []*System, inputs, outputs []string, sums ...Sum) (*System, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("Connect: no blocks")
+	}
+	rs := make([]realization, len(blocks))
+	for i, b := range blocks {
+		rs[i] = b.realization()
+	}
+	r, err := connect(rs, inputs, outputs, sums)
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+//ConnectDiscrete wires discrete blocks into a single system by matching
+//signal names, see Connect. All blocks must have the same sample time.
+func ConnectDiscrete(blocks []*Discrete, inputs, outputs []string, sums ...Sum) (*Discrete, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("Connect: no blocks")
+	}
+	if err := checkSampleTimes(blocks...); err != nil {
+		return nil, err
+	}
+	rs := make([]realization, len(blocks))
+	for i, b := range blocks {
+		rs[i] = b.realization()
+	}
+	r, err := connect(rs, inputs, outputs, sums)
+	if err != nil {
+		return nil, err
+	}
+	return r.discrete(blocks[0].Dt)
+}
+
+// connect wires the realizations by the names of their signals
+func connect(rs []realization, inputs, outputs []string, sums []Sum) (realization, error) {
+	if len(inputs) == 0 || len(outputs) == 0 {
+		return realization{}, errors.New("Connect: inputs and outputs should not be empty")
+	}
+	for _, r := range rs {
+		if r.hasDelays() {
+			return realization{}, ErrDelays
+		}
+	}
+	total := appendRealizations(rs...)
+	_, mu, py := total.dims()
+	nv := len(inputs)
+
+	// every signal is a linear combination of the block outputs y
+	// and the external inputs v, i.e. a row vector of length py + nv
+	sources := make(map[string][]float64)
+	units := make(map[string]string)
+	define := func(name, unit string, idx int) error {
+		if name == "" {
+			return nil
+		}
+		if _, ok := sources[name]; ok {
+			return fmt.Errorf("Connect: signal %q is defined more than once", name)
+		}
+		row := make([]float64, py+nv)
+		row[idx] = 1
+		sources[name] = row
+		units[name] = unit
+		return nil
+	}
+	for i := 0; i < py; i++ {
+		sig := signalAt(total.outputs, i)
+		if err := define(sig.Name, sig.Unit, i); err != nil {
+			return realization{}, err
+		}
+	}
+	for i, name := range inputs {
+		if name == "" {
+			return realization{}, errors.New("Connect: external inputs must be named")
+		}
+		if err := define(name, "", py+i); err != nil {
+			return realization{}, err
+		}
+	}
+
+	junctions := make(map[string]Sum)
+	for _, sum := range sums {
+		if _, ok := sources[sum.Output]; ok {
+			return realization{}, fmt.Errorf("Connect: signal %q is defined more than once", sum.Output)
+		}
+		if _, ok := junctions[sum.Output]; ok {
+			return realization{}, fmt.Errorf("Connect: signal %q is defined more than once", sum.Output)
+		}
+		if sum.Signs != nil && len(sum.Signs) != len(sum.Inputs) {
+			return realization{}, fmt.Errorf("Connect: sum %q should have a sign for every input", sum.Output)
+		}
+		junctions[sum.Output] = sum
+	}
+
+	// resolve summing junctions recursively
+	visiting := make(map[string]bool)
+	var resolve func(name string) ([]float64, error)
+	resolve = func(name string) ([]float64, error) {
+		if row, ok := sources[name]; ok {
+			return row, nil
+		}
+		sum, ok := junctions[name]
+		if !ok {
+			return nil, fmt.Errorf("Connect: signal %q is not defined", name)
+		}
+		if visiting[name] {
+			return nil, fmt.Errorf("Connect: summing junction %q depends on itself", name)
+		}
+		visiting[name] = true
+		row := make([]float64, py+nv)
+		for k, in := range sum.Inputs {
+			src, err := resolve(in)
+			if err != nil {
+				return nil, err
+			}
+			sign := 1.0
+			if sum.Signs != nil {
+				sign = sum.Signs[k]
+			}
+			for j := range row {
+				row[j] += sign * src[j]
+			}
+			if units[name] == "" {
+				units[name] = units[in]
+			}
+		}
+		visiting[name] = false
+		sources[name] = row
+		return row, nil
+	}
+
+	// u = M y + N v
+	m := mat.NewDense(mu, py, nil)
+	n := mat.NewDense(mu, nv, nil)
+	for i := 0; i < mu; i++ {
+		sig := signalAt(total.inputs, i)
+		if sig.Name == "" {
+			return realization{}, fmt.Errorf("Connect: block input %d has no name", i)
+		}
+		row, err := resolve(sig.Name)
+		if err != nil {
+			return realization{}, err
+		}
+		m.SetRow(i, row[:py])
+		n.SetRow(i, row[py:])
+		if units[sig.Name] == "" {
+			units[sig.Name] = sig.Unit
+		}
+	}
+
+	// out = S y + T v
+	s := mat.NewDense(len(outputs), py, nil)
+	t := mat.NewDense(len(outputs), nv, nil)
+	for i, name := range outputs {
+		row, err := resolve(name)
+		if err != nil {
+			return realization{}, err
+		}
+		s.SetRow(i, row[:py])
+		t.SetRow(i, row[py:])
+	}
+
+	res, err := total.interconnect(m, n, s, t)
+	if err != nil {
+		return realization{}, err
+	}
+	res.inputs = make([]Signal, nv)
+	for i, name := range inputs {
+		res.inputs[i] = Signal{Name: name, Unit: units[name]}
+	}
+	res.outputs = make([]Signal, len(outputs))
+	for i, name := range outputs {
+		res.outputs[i] = Signal{Name: name, Unit: units[name]}
+	}
+	return res, nil
+}
+
+// signalIndex returns the index of the signal with the given name
+func signalIndex(signals []Signal, name string) (int, error) {
+	for i, s := range signals {
+		if s.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("signal %q not found", name)
+}
+
+// signalAt returns the signal i or an unnamed signal
+func signalAt(signals []Signal, i int) Signal {
+	if i < len(signals) {
+		return signals[i]
+	}
+	return Signal{}
+}
+
+// copySignals returns a copy of the signals
+func copySignals(signals []Signal) []Signal {
+	if signals == nil {
+		return nil
+	}
+	return append([]Signal(nil), signals...)
+}
+
+// selectSignals returns the signals with the given indices
+func selectSignals(signals []Signal, idx []int) []Signal {
+	if signals == nil {
+		return nil
+	}
+	res := make([]Signal, len(idx))
+	for i, k := range idx {
+		res[i] = signalAt(signals, k)
+	}
+	return res
+}
+
+// concatSignals concatenates the signals of several systems with the
+// given number of channels; unnamed systems are padded with empty signals
+func concatSignals(signals [][]Signal, sizes []int) []Signal {
+	named := false
+	for _, s := range signals {
+		if len(s) > 0 {
+			named = true
+		}
+	}
+	if !named {
+		return nil
+	}
+	var res []Signal
+	for i, s := range signals {
+		for k := 0; k < sizes[i]; k++ {
+			res = append(res, signalAt(s, k))
+		}
+	}
+	return res
+}
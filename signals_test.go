@@ -0,0 +1,96 @@
+package lti
+
+import (
+	"fmt"
+	"math/cmplx"
+	"testing"
+)
+
+func TestConnect(t *testing.T) {
+	plant := newTestFirstOrder(2, 1, 0)
+	plant.Inputs = []Signal{{Name: "u", Unit: "kW"}}
+	plant.Outputs = []Signal{{Name: "y", Unit: "degC"}}
+	plant.States = []Signal{{Name: "T"}}
+
+	ctrl := newTestFirstOrder(3, 0.1, 1)
+	ctrl.Inputs = []Signal{{Name: "e"}}
+	ctrl.Outputs = []Signal{{Name: "u"}}
+
+	cl, err := Connect(
+		[]*System{plant, ctrl},
+		[]string{"r"},
+		[]string{"y", "u", "e"},
+		Sum{Output: "e", Inputs: []string{"r", "y"}, Signs: []float64{1, -1}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reference: L = G K, T = L / (1 + L)
+	loop, err := ctrl.Series(plant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := 0.7
+	h, err := cl.FrequencyResponse([]float64{w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := sisoAt(t, loop, w)
+	k := sisoAt(t, ctrl, w)
+	if cmplx.Abs(h[0].At(0, 0)-l/(1+l)) > 1e-12 ||
+		cmplx.Abs(h[0].At(1, 0)-k/(1+l)) > 1e-12 ||
+		cmplx.Abs(h[0].At(2, 0)-1/(1+l)) > 1e-12 {
+		fmt.Println("received:", h[0])
+		t.Error("Connect returned wrong closed loop")
+	}
+
+	// signals are preserved
+	if cl.Inputs[0].Name != "r" || cl.Outputs[0] != (Signal{Name: "y", Unit: "degC"}) || cl.Outputs[1].Unit != "kW" {
+		fmt.Println(cl.Inputs, cl.Outputs)
+		t.Error("wrong signals of connected system")
+	}
+	if idx, err := cl.StateIndex("T"); err != nil || idx != 0 {
+		t.Error("state names not preserved")
+	}
+
+	// undefined signals and duplicate definitions
+	if _, err := Connect([]*System{plant, ctrl}, []string{"r"}, []string{"y"}); err == nil {
+		t.Error("expected error for unconnected input e")
+	}
+	if _, err := Connect([]*System{plant, ctrl}, []string{"u"}, []string{"y"}); err == nil {
+		t.Error("expected error for duplicate signal u")
+	}
+}
+
+func TestSignalsPreserved(t *testing.T) {
+	plant := newTestFirstOrder(2, 1, 0)
+	plant.Inputs = []Signal{{Name: "u"}}
+	plant.Outputs = []Signal{{Name: "y"}}
+	sensor := newTestFirstOrder(10, 10, 0)
+	sensor.Outputs = []Signal{{Name: "ym"}}
+
+	series, err := plant.Series(sensor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series.Inputs[0].Name != "u" || series.Outputs[0].Name != "ym" {
+		t.Error("Series lost signal names")
+	}
+
+	app, err := plant.Append(sensor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(app.Inputs) != 2 || app.Inputs[0].Name != "u" || app.Inputs[1].Name != "" || app.Outputs[1].Name != "ym" {
+		t.Error("Append lost signal names:", app.Inputs, app.Outputs)
+	}
+
+	disc, err := series.Discretize(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx, err := disc.OutputIndex("ym"); err != nil || idx != 0 {
+		t.Error("Discretize lost signal names")
+	}
+}
@@ -0,0 +1,79 @@
+package lti
+
+import (
+	"errors"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//Simulation contains the trajectories of a simulation with N steps.
+//
+// The fields are:
+// 	States:      States x(0), ..., x(N)
+// 	Outputs:     Outputs y(0), ..., y(N-1)
+// 	Commanded:   Commanded inputs u(0), ..., u(N-1)
+// 	Inputs:      Inputs applied by the actuator
+// 	Saturations: Saturated channels and steps of the actuator
+//
+type Simulation struct {
+	States      []*mat.VecDense
+	Outputs     []*mat.VecDense
+	Commanded   []*mat.VecDense
+	Inputs      []*mat.VecDense
+	Saturations []SaturationEvent
+}
+
+//Controller returns the commanded input u(k) for the state x(k) at step k
+type Controller func(k int, x *mat.VecDense) *mat.VecDense
+
+//Simulate propagates the state x0 with the commanded inputs, which pass the
+//actuator before Predict. The actuator may be nil for ideal actuators; it is
+//not reset, so that it can continue a previous simulation.
+func (d *Discrete) Simulate(x0 *mat.VecDense, inputs []*mat.VecDense, act *Actuator) (*Simulation, error) {
+	return d.simulate(x0, len(inputs), func(k int, x *mat.VecDense) *mat.VecDense {
+		return inputs[k]
+	}, act)
+}
+
+//SimulateFeedback simulates N steps of the closed loop with the controller,
+//whose commanded inputs pass the actuator before Predict, see Simulate.
+func (d *Discrete) SimulateFeedback(x0 *mat.VecDense, steps int, controller Controller, act *Actuator) (*Simulation, error) {
+	return d.simulate(x0, steps, controller, act)
+}
+
+// simulate propagates the state with the commanded inputs of the controller
+func (d *Discrete) simulate(x0 *mat.VecDense, steps int, controller Controller, act *Actuator) (*Simulation, error) {
+	n, m := d.Bd.Dims()
+	if x0.Len() != n {
+		return nil, errors.New("Simulate: x0 should match the number of states")
+	}
+
+	sim := &Simulation{States: []*mat.VecDense{mat.VecDenseCopyOf(x0)}}
+	logged := 0
+	if act != nil {
+		logged = len(act.Log)
+	}
+	x := x0
+	for k := 0; k < steps; k++ {
+		cmd := controller(k, mat.VecDenseCopyOf(x))
+		if cmd == nil || cmd.Len() != m {
+			return nil, errors.New("Simulate: inputs should match the number of inputs")
+		}
+		u := cmd
+		if act != nil {
+			var err error
+			if u, err = act.Apply(cmd); err != nil {
+				return nil, err
+			}
+		}
+		sim.Commanded = append(sim.Commanded, mat.VecDenseCopyOf(cmd))
+		sim.Inputs = append(sim.Inputs, mat.VecDenseCopyOf(u))
+		sim.Outputs = append(sim.Outputs, mat.VecDenseCopyOf(d.Response(x, u)))
+		x = mat.VecDenseCopyOf(d.Predict(x, u))
+		sim.States = append(sim.States, x)
+	}
+	if act != nil {
+		sim.Saturations = append(sim.Saturations, act.Log[logged:]...)
+	}
+	return sim, nil
+}
@@ -0,0 +1,82 @@
+package lti
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSimulate(t *testing.T) {
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x0 := mat.NewVecDense(2, []float64{0, 0})
+	inputs := make([]*mat.VecDense, 10)
+	for k := range inputs {
+		inputs[k] = mat.NewVecDense(1, []float64{2})
+	}
+
+	// ideal actuator
+	sim, err := plant.Simulate(x0, inputs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := mat.VecDenseCopyOf(x0)
+	for k := range inputs {
+		x = mat.VecDenseCopyOf(plant.Predict(x, inputs[k]))
+	}
+	if len(sim.States) != 11 || len(sim.Outputs) != 10 || !mat.EqualApprox(sim.States[10], x, 1e-12) {
+		t.Error("Simulate returned wrong states:", sim.States[len(sim.States)-1], x)
+	}
+
+	// saturated actuator
+	act := &Actuator{Max: []float64{1}}
+	sim, err = plant.Simulate(x0, inputs, act)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sim.Saturations) != 10 {
+		t.Error("Simulate should log the saturation at every step, received:", len(sim.Saturations))
+	}
+	if v := sim.States[10].AtVec(1); v > 1.0+1e-9 || v < 1.0-1e-9 {
+		t.Error("saturated velocity should be 10 * 0.1 * 1, received:", v)
+	}
+	if sim.Commanded[0].AtVec(0) != 2 || sim.Inputs[0].AtVec(0) != 1 {
+		t.Error("Simulate should record the commanded and applied inputs")
+	}
+}
+
+func TestSimulateFeedback(t *testing.T) {
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := mat.NewDense(1, 2, []float64{4, 3})
+	controller := func(step int, x *mat.VecDense) *mat.VecDense {
+		var u mat.VecDense
+		u.MulVec(k, x)
+		u.ScaleVec(-1, &u)
+		return &u
+	}
+	act := &Actuator{Min: []float64{-0.5}, Max: []float64{0.5}, RateLimit: []float64{0.1}}
+	x0 := mat.NewVecDense(2, []float64{1, 0})
+	sim, err := plant.SimulateFeedback(x0, 300, controller, act)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for step, u := range sim.Inputs {
+		if u.AtVec(0) < -0.5 || u.AtVec(0) > 0.5 {
+			t.Fatal("applied input violates the bounds at step", step, ":", u.AtVec(0))
+		}
+	}
+	if len(sim.Saturations) == 0 {
+		t.Error("the initial error should saturate the actuator")
+	}
+	if x := sim.States[300]; mat.Norm(x, 2) > 1e-3 {
+		t.Error("saturated loop should still converge, received:", x.RawVector().Data)
+	}
+	if _, err := plant.SimulateFeedback(mat.NewVecDense(3, nil), 1, controller, nil); err == nil {
+		t.Error("expected error for wrong size of x0")
+	}
+}
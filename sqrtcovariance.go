@@ -0,0 +1,773 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//SqrtCovariance propagates the covariance in square-root form.
+//Instead of p(k) the upper triangular factor S(k) with p(k) = S(k)^T * S(k)
+//is propagated by a QR factorization of the stacked matrix
+//
+// [ S(k) * Md^T ]
+// [ W * G^T     ]
+//
+//where W^T * W = Q. The propagated covariance is positive semi-definite
+//and symmetric by construction.
+//
+//The factor API SetFactor, PredictFactor, UpdateFactor and Factor is the
+//supported path for long-running estimators: the covariance is never formed
+//and the factor is propagated without allocations. W * G^T is computed once
+//and again only if G or Q change.
+//
+//SqrtCovariance also implements CovariancePredictor, so that it can replace
+//a Covariance at the same call sites. PredictSym and PredictTo only reuse the
+//factor if p equals their last result; any other p, e.g. a covariance after
+//a measurement update, is factorized again, which gives up the robustness
+//of the square-root form.
+type SqrtCovariance struct {
+	Md *mat.Dense
+	G  *mat.Dense
+	Q  mat.Symmetric
+
+	s     mat.Dense    // Square-root factor of p
+	p     mat.SymDense // Last predicted covariance returned by PredictSym
+	last  mat.SymDense // Covariance S^T * S of the factor
+	plain Covariance   // Propagation of covariances without a factor
+
+	noise   noiseCache    // G and Q of wgt
+	wgt     mat.Dense     // W * G^T
+	smt     mat.Dense     // Workspace S * Md^T
+	stacked mat.Dense     // Workspace [S * Md^T; W * G^T]
+	mdt, st mat.Transpose // Md^T and S^T
+}
+
+//NewSqrtCovariance creates a new square-root covariance struct with the
+//discretized matrix Md, the noise input matrix G and the noise spectral density Q.
+//G and Q follow the same rules as for NewCovarianceWithNoise; Q may be nil
+//and must be positive semi-definite otherwise.
+func NewSqrtCovariance(md, g *mat.Dense, q mat.Symmetric) (*SqrtCovariance, error) {
+	if q == nil {
+		if r, c := md.Dims(); r != c {
+			return nil, errors.New("Md should be squared")
+		}
+		return &SqrtCovariance{Md: md, G: g}, nil
+	}
+	c, err := NewCovarianceWithNoise(md, g, q)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sqrtFactor(q); err != nil {
+		return nil, err
+	}
+	return &SqrtCovariance{Md: c.Md, G: c.G, Q: c.Q}, nil
+}
+
+//PredictSym propagates the covariance p(k) to p(k+1)
+//according to p(k+1) = Md * p(k) * Md^T + G * Q * G^T.
+//The returned matrix is owned by c and is overwritten by the next call
+//to PredictSym.
+func (c *SqrtCovariance) PredictSym(p mat.Symmetric) *mat.SymDense {
+	c.PredictTo(&c.p, p)
+	return &c.p
+}
+
+//PredictTo propagates the covariance p(k) and stores p(k+1) in dst.
+//dst may be p. The factor is reused if p equals the last result and p is
+//factorized otherwise. p should be positive semi-definite; if it cannot be
+//factorized, p is propagated as by Covariance.PredictTo and the factor is cleared.
+func (c *SqrtCovariance) PredictTo(dst *mat.SymDense, p mat.Symmetric) {
+	if c.s.IsEmpty() || !equalSym(p, &c.last) {
+		if err := c.SetCovariance(p); err != nil {
+			c.clear()
+			predictPlain(&c.plain, c.Md, c.G, c.Q, dst, p)
+			return
+		}
+	}
+	if err := c.PredictFactor(); err != nil {
+		c.clear()
+		predictPlain(&c.plain, c.Md, c.G, c.Q, dst, p)
+		return
+	}
+	copySym(dst, &c.last)
+}
+
+//SetCovariance initializes the square-root factor from the covariance p.
+//p must be positive semi-definite.
+func (c *SqrtCovariance) SetCovariance(p mat.Symmetric) error {
+	s, err := sqrtFactor(p)
+	if err != nil {
+		return err
+	}
+	c.setFactor(s, 0)
+	return nil
+}
+
+//SetFactor initializes the square-root factor with the upper triangular
+//part of s, i.e. the covariance is p = S^T * S.
+func (c *SqrtCovariance) SetFactor(s mat.Matrix) error {
+	n, _ := c.Md.Dims()
+	if r, k := s.Dims(); r != n || k != n {
+		return errors.New("factor should match the dimension of Md")
+	}
+	c.setFactor(s, 0)
+	return nil
+}
+
+//Factor returns a copy of the current upper triangular factor S with p = S^T * S.
+func (c *SqrtCovariance) Factor() *mat.Dense {
+	var s mat.Dense
+	s.CloneFrom(&c.s)
+	return &s
+}
+
+//Covariance returns the covariance p = S^T * S of the current factor.
+//The returned matrix is owned by c.
+func (c *SqrtCovariance) Covariance() *mat.SymDense {
+	copySym(&c.p, &c.last)
+	return &c.p
+}
+
+//PredictFactor propagates the internal square-root factor by one time step.
+func (c *SqrtCovariance) PredictFactor() error {
+	if c.s.IsEmpty() {
+		return errors.New("square-root factor is not initialized")
+	}
+	n, _ := c.Md.Dims()
+	rows := n
+	if c.Q != nil {
+		if !c.noise.matches(c.G, c.Q) {
+			w, err := sqrtFactor(c.Q)
+			if err != nil {
+				return err
+			}
+			// W * G^T
+			c.wgt.Reset()
+			if c.G != nil {
+				c.wgt.Mul(w, c.G.T())
+			} else {
+				c.wgt.CloneFrom(w)
+			}
+			c.noise.set(c.G, c.Q)
+		}
+		rows += c.Q.Symmetric()
+	}
+
+	// [S(k) * Md^T; W * G^T]
+	c.mdt.Matrix = c.Md
+	c.smt.Mul(&c.s, &c.mdt)
+	reuseDense(&c.stacked, rows, n)
+	for i := 0; i < n; i++ {
+		copy(c.stacked.RawRowView(i), c.smt.RawRowView(i))
+	}
+	for i := n; i < rows; i++ {
+		copy(c.stacked.RawRowView(i), c.wgt.RawRowView(i-n))
+	}
+
+	triangularize(&c.stacked)
+	c.setFactor(&c.stacked, 0)
+	return nil
+}
+
+//UpdateFactor updates the square-root factor with the measurement
+//y = H * x + v with the noise covariance R and returns the Kalman gain
+// 	K = p * H^T * (H * p * H^T + R)^-1
+//The factor of p - K * H * p follows from the QR factorization of
+//
+// [ V       0 ]
+// [ S * H^T S ]
+//
+//where V^T * V = R.
+func (c *SqrtCovariance) UpdateFactor(h *mat.Dense, r mat.Symmetric) (*mat.Dense, error) {
+	if c.s.IsEmpty() {
+		return nil, errors.New("square-root factor is not initialized")
+	}
+	n, _ := c.Md.Dims()
+	m, hc := h.Dims()
+	if hc != n {
+		return nil, errors.New("H col should be equal to Md row dim")
+	}
+	if r.Symmetric() != m {
+		return nil, errors.New("R dim should be equal to H row dim")
+	}
+	v, err := sqrtFactor(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pre := mat.NewDense(m+n, m+n, nil)
+	pre.Slice(0, m, 0, m).(*mat.Dense).Copy(v)
+	pre.Slice(m, m+n, 0, m).(*mat.Dense).Mul(&c.s, h.T())
+	pre.Slice(m, m+n, m, m+n).(*mat.Dense).Copy(&c.s)
+
+	var qr mat.QR
+	qr.Factorize(pre)
+	var post mat.Dense
+	qr.RTo(&post)
+
+	// [X Y; 0 Z] with X^T X = H p H^T + R, X^T Y = H p and Z^T Z = p - K H p
+	var kt mat.Dense
+	if err := kt.Solve(post.Slice(0, m, 0, m), post.Slice(0, m, m, m+n)); err != nil {
+		return nil, errors.New("innovation covariance is singular")
+	}
+	c.setFactor(&post, m)
+	return mat.DenseCopyOf(kt.T()), nil
+}
+
+// setFactor sets the factor to the upper triangular block of r at the row
+// and column off and updates the covariance of the factor
+func (c *SqrtCovariance) setFactor(r mat.Matrix, off int) {
+	n, _ := c.Md.Dims()
+	reuseDense(&c.s, n, n)
+	positiveDiagonal(&c.s, r, off, n)
+	if !c.last.IsEmpty() && c.last.Symmetric() != n {
+		c.last.Reset()
+	}
+	c.st.Matrix = &c.s
+	c.last.SymOuterK(1, &c.st)
+}
+
+// clear removes the factor
+func (c *SqrtCovariance) clear() {
+	c.s.Reset()
+	c.last.Reset()
+}
+
+//UDCovariance propagates the covariance in the factored form p = U * D * U^T
+//with a unit upper triangular U and a diagonal D using the Thornton
+//modified weighted Gram-Schmidt time update (Bierman-Thornton).
+//
+//As for SqrtCovariance, the factor API SetFactors, PredictFactors,
+//UpdateFactors and Factors is the supported path and propagates the factors
+//without allocations; PredictSym and PredictTo factorize any p that differs
+//from their last result.
+type UDCovariance struct {
+	Md *mat.Dense
+	G  *mat.Dense
+	Q  mat.Symmetric
+
+	u     mat.Dense    // Unit upper triangular factor
+	d     []float64    // Diagonal factor
+	p     mat.SymDense // Last predicted covariance returned by PredictSym
+	last  mat.SymDense // Covariance U * D * U^T of the factors
+	plain Covariance   // Propagation of covariances without factors
+
+	noise   noiseCache    // G and Q of guq and dq
+	guq     mat.Dense     // G * Uq with Q = Uq * Dq * Uq^T
+	dq      []float64     // Dq
+	mu, w   mat.Dense     // Workspace Md * U and [Md * U, G * Uq]
+	dw      []float64     // Workspace diag(D, Dq)
+	un      mat.Dense     // Workspace of the propagated U
+	dn      []float64     // Workspace of the propagated D
+	ud, udu mat.Dense     // Workspace U * D and U * D * U^T
+	ut      mat.Transpose // U^T
+}
+
+//NewUDCovariance creates a new UD covariance struct with the discretized
+//matrix Md, the noise input matrix G and the noise spectral density Q.
+func NewUDCovariance(md, g *mat.Dense, q mat.Symmetric) (*UDCovariance, error) {
+	sc, err := NewSqrtCovariance(md, g, q)
+	if err != nil {
+		return nil, err
+	}
+	return &UDCovariance{Md: sc.Md, G: sc.G, Q: sc.Q}, nil
+}
+
+//PredictSym propagates the covariance p(k) to p(k+1)
+//according to p(k+1) = Md * p(k) * Md^T + G * Q * G^T.
+//The returned matrix is owned by c and is overwritten by the next call
+//to PredictSym.
+func (c *UDCovariance) PredictSym(p mat.Symmetric) *mat.SymDense {
+	c.PredictTo(&c.p, p)
+	return &c.p
+}
+
+//PredictTo propagates the covariance p(k) and stores p(k+1) in dst,
+//see SqrtCovariance.PredictTo.
+func (c *UDCovariance) PredictTo(dst *mat.SymDense, p mat.Symmetric) {
+	if c.u.IsEmpty() || !equalSym(p, &c.last) {
+		if err := c.SetCovariance(p); err != nil {
+			c.clear()
+			predictPlain(&c.plain, c.Md, c.G, c.Q, dst, p)
+			return
+		}
+	}
+	if err := c.PredictFactors(); err != nil {
+		c.clear()
+		predictPlain(&c.plain, c.Md, c.G, c.Q, dst, p)
+		return
+	}
+	copySym(dst, &c.last)
+}
+
+//SetCovariance initializes the U and D factors from the covariance p.
+func (c *UDCovariance) SetCovariance(p mat.Symmetric) error {
+	u, d, err := udFactor(p)
+	if err != nil {
+		return err
+	}
+	c.setFactors(u, d)
+	return nil
+}
+
+//SetFactors initializes the factors with the unit upper triangular part
+//of u and the diagonal d, i.e. the covariance is p = U * D * U^T.
+func (c *UDCovariance) SetFactors(u mat.Matrix, d []float64) error {
+	n, _ := c.Md.Dims()
+	if r, k := u.Dims(); r != n || k != n || len(d) != n {
+		return errors.New("factors should match the dimension of Md")
+	}
+	uu := mat.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		if d[i] < 0 {
+			return errors.New("D should not be negative")
+		}
+		uu.Set(i, i, 1)
+		for j := i + 1; j < n; j++ {
+			uu.Set(i, j, u.At(i, j))
+		}
+	}
+	c.setFactors(uu, append([]float64(nil), d...))
+	return nil
+}
+
+//Factors returns copies of the current factors U and D with p = U * D * U^T.
+func (c *UDCovariance) Factors() (*mat.Dense, []float64) {
+	var u mat.Dense
+	u.CloneFrom(&c.u)
+	return &u, append([]float64(nil), c.d...)
+}
+
+//Covariance returns the covariance p = U * D * U^T of the current factors.
+//The returned matrix is owned by c.
+func (c *UDCovariance) Covariance() *mat.SymDense {
+	copySym(&c.p, &c.last)
+	return &c.p
+}
+
+//PredictFactors propagates the internal U and D factors by one time step.
+func (c *UDCovariance) PredictFactors() error {
+	if c.u.IsEmpty() {
+		return errors.New("UD factors are not initialized")
+	}
+	n, _ := c.Md.Dims()
+	m := n
+	if c.Q != nil {
+		if !c.noise.matches(c.G, c.Q) {
+			uq, dq, err := udFactor(c.Q)
+			if err != nil {
+				return err
+			}
+			c.guq.Reset()
+			if c.G != nil {
+				c.guq.Mul(c.G, uq)
+			} else {
+				c.guq.CloneFrom(uq)
+			}
+			c.dq = dq
+			c.noise.set(c.G, c.Q)
+		}
+		m += len(c.dq)
+	}
+
+	// W = [Md * U, G * Uq], Dw = diag(D, Dq)
+	c.mu.Mul(c.Md, &c.u)
+	reuseDense(&c.w, n, m)
+	for i := 0; i < n; i++ {
+		row := c.w.RawRowView(i)
+		copy(row, c.mu.RawRowView(i))
+		if m > n {
+			copy(row[n:], c.guq.RawRowView(i))
+		}
+	}
+	c.dw = resizeFloats(c.dw, m)
+	copy(c.dw, c.d)
+	copy(c.dw[n:], c.dq)
+
+	// modified weighted Gram-Schmidt orthogonalization of the rows of W
+	reuseDense(&c.un, n, n)
+	c.un.Zero()
+	u, d, w, dw := &c.un, resizeFloats(c.dn, n), &c.w, c.dw
+	for j := n - 1; j >= 0; j-- {
+		wj := w.RawRowView(j)
+		d[j] = 0
+		for k := 0; k < m; k++ {
+			d[j] += dw[k] * wj[k] * wj[k]
+		}
+		u.Set(j, j, 1)
+		if d[j] <= 0 {
+			// singular direction: the remaining rows are left untouched
+			d[j] = 0
+			continue
+		}
+		for i := 0; i < j; i++ {
+			wi := w.RawRowView(i)
+			var sum float64
+			for k := 0; k < m; k++ {
+				sum += dw[k] * wi[k] * wj[k]
+			}
+			uij := sum / d[j]
+			u.Set(i, j, uij)
+			for k := 0; k < m; k++ {
+				wi[k] -= uij * wj[k]
+			}
+		}
+	}
+
+	// the propagated factors replace the factors, which become the workspace
+	c.u, c.un = c.un, c.u
+	c.d, c.dn = d, c.d
+	c.updateCovariance()
+	return nil
+}
+
+//UpdateFactors updates the U and D factors with the measurement
+//y = H * x + v with the positive definite noise covariance R and returns
+//the Kalman gain, see SqrtCovariance.UpdateFactor. The measurements are
+//decorrelated with the UD factors of R and processed one by one with the
+//Bierman scalar update.
+func (c *UDCovariance) UpdateFactors(h *mat.Dense, r mat.Symmetric) (*mat.Dense, error) {
+	if c.u.IsEmpty() {
+		return nil, errors.New("UD factors are not initialized")
+	}
+	n, _ := c.Md.Dims()
+	m, hc := h.Dims()
+	if hc != n {
+		return nil, errors.New("H col should be equal to Md row dim")
+	}
+	if r.Symmetric() != m {
+		return nil, errors.New("R dim should be equal to H row dim")
+	}
+	ur, dr, err := udFactor(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range dr {
+		if v <= 0 {
+			return nil, errors.New("R should be positive definite")
+		}
+	}
+
+	// K = p H^T (H p H^T + R)^-1 of the prior
+	var pht, s mat.Dense
+	pht.Mul(&c.last, h.T())
+	s.Mul(h, &pht)
+	s.Add(&s, r)
+	var kt mat.Dense
+	if err := kt.Solve(&s, pht.T()); err != nil {
+		return nil, errors.New("innovation covariance is singular")
+	}
+
+	// uncorrelated measurements Ur^-1 y with the variances Dr
+	var hd mat.Dense
+	if err := hd.Solve(ur, h); err != nil {
+		return nil, err
+	}
+	u := mat.DenseCopyOf(&c.u)
+	d := append([]float64(nil), c.d...)
+	for i := 0; i < m; i++ {
+		biermanUpdate(u, d, hd.RawRowView(i), dr[i])
+	}
+	c.setFactors(u, d)
+	return mat.DenseCopyOf(kt.T()), nil
+}
+
+// setFactors sets the factors and the covariance of the factors
+func (c *UDCovariance) setFactors(u *mat.Dense, d []float64) {
+	c.u.Reset()
+	c.u.CloneFrom(u)
+	c.d = d
+	c.updateCovariance()
+}
+
+// clear removes the factors
+func (c *UDCovariance) clear() {
+	c.u.Reset()
+	c.d = nil
+	c.last.Reset()
+}
+
+// updateCovariance calculates p = U * D * U^T from the factors
+func (c *UDCovariance) updateCovariance() {
+	n := len(c.d)
+	reuseDense(&c.ud, n, n)
+	c.ud.Copy(&c.u)
+	for j := 0; j < n; j++ {
+		for i := 0; i <= j; i++ {
+			c.ud.Set(i, j, c.ud.At(i, j)*c.d[j])
+		}
+	}
+	c.ut.Matrix = &c.u
+	reuseDense(&c.udu, n, n)
+	c.udu.Mul(&c.ud, &c.ut)
+	if !c.last.IsEmpty() && c.last.Symmetric() != n {
+		c.last.Reset()
+	}
+	symmetrize(&c.last, &c.udu)
+}
+
+// biermanUpdate updates the factors u and d in place with the scalar
+// measurement h * x + v with the variance r
+func biermanUpdate(u *mat.Dense, d []float64, h []float64, r float64) {
+	n := len(d)
+
+	// f = U^T h, v = D f
+	f := make([]float64, n)
+	v := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i <= j; i++ {
+			f[j] += u.At(i, j) * h[i]
+		}
+		v[j] = d[j] * f[j]
+	}
+
+	b := make([]float64, n)
+	alpha := r
+	for j := 0; j < n; j++ {
+		beta := alpha
+		alpha += f[j] * v[j]
+		lambda := -f[j] / beta
+		d[j] *= beta / alpha
+		for i := 0; i < j; i++ {
+			uij := u.At(i, j)
+			u.Set(i, j, uij+b[i]*lambda)
+			b[i] += v[j] * uij
+		}
+		b[j] = v[j]
+	}
+}
+
+// predictPlain propagates p without factorization with the workspace c
+func predictPlain(c *Covariance, md, g *mat.Dense, q mat.Symmetric, dst *mat.SymDense, p mat.Symmetric) {
+	c.Md, c.G, c.Q = md, g, q
+	c.PredictTo(dst, p)
+}
+
+// equalSym returns true if a and b have the same size and elements
+func equalSym(a mat.Symmetric, b *mat.SymDense) bool {
+	if b.IsEmpty() {
+		return false
+	}
+	n := b.Symmetric()
+	if a.Symmetric() != n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			if a.At(i, j) != b.At(i, j) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// copySym copies src to dst, resizing an empty dst
+func copySym(dst, src *mat.SymDense) {
+	if dst == src {
+		return
+	}
+	if dst.IsEmpty() {
+		dst.ReuseAsSym(src.Symmetric())
+	}
+	dst.CopySym(src)
+}
+
+// positiveDiagonal stores the n x n upper triangular block of r starting at
+// the row and column off in the n x n matrix s with the rows scaled to a
+// non-negative diagonal
+func positiveDiagonal(s *mat.Dense, r mat.Matrix, off, n int) {
+	for i := 0; i < n; i++ {
+		sign := 1.0
+		if r.At(off+i, off+i) < 0 {
+			sign = -1
+		}
+		for j := 0; j < n; j++ {
+			v := 0.0
+			if j >= i {
+				v = sign * r.At(off+i, off+j)
+			}
+			s.Set(i, j, v)
+		}
+	}
+}
+
+// triangularize reduces a in place to the upper triangular R of a = Q * R
+// by Householder reflections
+func triangularize(a *mat.Dense) {
+	raw := a.RawMatrix()
+	m, n, data, stride := raw.Rows, raw.Cols, raw.Data, raw.Stride
+	for k := 0; k < n && k < m; k++ {
+		var norm float64
+		for i := k; i < m; i++ {
+			norm += data[i*stride+k] * data[i*stride+k]
+		}
+		if norm == 0 {
+			continue
+		}
+		norm = math.Sqrt(norm)
+
+		// reflection of x = a(k:m, k) onto alpha e1 with v = x - alpha e1,
+		// which is stored in place of x
+		x1 := data[k*stride+k]
+		alpha := -math.Copysign(norm, x1)
+		data[k*stride+k] = x1 - alpha
+		vv := 2 * norm * (norm + math.Abs(x1))
+		for j := k + 1; j < n; j++ {
+			var dot float64
+			for i := k; i < m; i++ {
+				dot += data[i*stride+k] * data[i*stride+j]
+			}
+			f := 2 * dot / vv
+			for i := k; i < m; i++ {
+				data[i*stride+j] -= f * data[i*stride+k]
+			}
+		}
+		data[k*stride+k] = alpha
+		for i := k + 1; i < m; i++ {
+			data[i*stride+k] = 0
+		}
+	}
+}
+
+// noiseCache keeps copies of G and Q to detect a changed noise model
+type noiseCache struct {
+	g     mat.Dense
+	q     mat.SymDense
+	valid bool
+}
+
+// matches returns true if g and q equal the stored copies
+func (c *noiseCache) matches(g *mat.Dense, q mat.Symmetric) bool {
+	if !c.valid || (g == nil) != c.g.IsEmpty() || !equalSym(q, &c.q) {
+		return false
+	}
+	if g == nil {
+		return true
+	}
+	r, k := g.Dims()
+	if gr, gk := c.g.Dims(); gr != r || gk != k {
+		return false
+	}
+	for i := 0; i < r; i++ {
+		for j := 0; j < k; j++ {
+			if g.At(i, j) != c.g.At(i, j) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// set stores copies of g and q
+func (c *noiseCache) set(g *mat.Dense, q mat.Symmetric) {
+	c.g.Reset()
+	if g != nil {
+		c.g.CloneFrom(g)
+	}
+	c.q.Reset()
+	c.q.ReuseAsSym(q.Symmetric())
+	c.q.CopySym(q)
+	c.valid = true
+}
+
+// reuseDense resizes m to r x c unless it already has this size
+func reuseDense(m *mat.Dense, r, c int) {
+	if mr, mc := m.Dims(); !m.IsEmpty() && (mr != r || mc != c) {
+		m.Reset()
+	}
+	if m.IsEmpty() {
+		m.ReuseAs(r, c)
+	}
+}
+
+// resizeFloats returns a slice of the length n reusing the capacity of s
+func resizeFloats(s []float64, n int) []float64 {
+	if cap(s) < n {
+		return make([]float64, n)
+	}
+	return s[:n]
+}
+
+// sqrtFactor returns an upper triangular matrix s with p = s^T * s.
+// Positive semi-definite matrices are handled by a QR factorization
+// of the symmetric square root.
+func sqrtFactor(p mat.Symmetric) (*mat.Dense, error) {
+	n := p.Symmetric()
+
+	var chol mat.Cholesky
+	if chol.Factorize(p) {
+		var u mat.TriDense
+		chol.UTo(&u)
+		s := mat.NewDense(n, n, nil)
+		s.Copy(&u)
+		return s, nil
+	}
+
+	// p = V * L * V^T = (L^1/2 V^T)^T (L^1/2 V^T)
+	var eig mat.EigenSym
+	if ok := eig.Factorize(p, true); !ok {
+		return nil, errors.New("sqrtFactor: eigen decomposition failed")
+	}
+	values := eig.Values(nil)
+	var v mat.Dense
+	eig.VectorsTo(&v)
+
+	tol := 1e-12 * math.Max(1, math.Abs(values[n-1]))
+	w := mat.NewDense(n, n, nil)
+	for i, l := range values {
+		if l < -tol {
+			return nil, errors.New("sqrtFactor: matrix is not positive semi-definite")
+		}
+		sl := math.Sqrt(math.Max(l, 0))
+		for j := 0; j < n; j++ {
+			w.Set(i, j, sl*v.At(j, i))
+		}
+	}
+
+	// triangularize w
+	var qr mat.QR
+	qr.Factorize(w)
+	var r mat.Dense
+	qr.RTo(&r)
+	s := mat.NewDense(n, n, nil)
+	positiveDiagonal(s, &r, 0, n)
+	return s, nil
+}
+
+// udFactor returns a unit upper triangular u and a diagonal d
+// with p = u * diag(d) * u^T
+func udFactor(p mat.Symmetric) (*mat.Dense, []float64, error) {
+	n := p.Symmetric()
+	u := mat.NewDense(n, n, nil)
+	d := make([]float64, n)
+	tol := 0.0
+	for i := 0; i < n; i++ {
+		tol = math.Max(tol, math.Abs(p.At(i, i)))
+	}
+	tol *= 1e-12
+
+	for j := n - 1; j >= 0; j-- {
+		sum := p.At(j, j)
+		for k := j + 1; k < n; k++ {
+			sum -= d[k] * u.At(j, k) * u.At(j, k)
+		}
+		if sum < -tol {
+			return nil, nil, errors.New("udFactor: matrix is not positive semi-definite")
+		}
+		d[j] = math.Max(sum, 0)
+		u.Set(j, j, 1)
+		for i := 0; i < j; i++ {
+			sum := p.At(i, j)
+			for k := j + 1; k < n; k++ {
+				sum -= u.At(i, k) * d[k] * u.At(j, k)
+			}
+			if d[j] > 0 {
+				u.Set(i, j, sum/d[j])
+			}
+		}
+	}
+	return u, d, nil
+}
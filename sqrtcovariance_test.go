@@ -0,0 +1,271 @@
+package lti
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func newTestCovariancePredictors(t *testing.T) []CovariancePredictor {
+	md := mat.NewDense(2, 2, []float64{
+		1, 0.1,
+		0, 0.9,
+	})
+	g := mat.NewDense(2, 1, []float64{
+		0.005,
+		0.1,
+	})
+	q := mat.NewSymDense(1, []float64{4})
+
+	cov, err := NewCovarianceWithNoise(md, g, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqrt, err := NewSqrtCovariance(md, g, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ud, err := NewUDCovariance(md, g, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []CovariancePredictor{cov, sqrt, ud}
+}
+
+func TestSqrtCovariancePredict(t *testing.T) {
+	predictors := newTestCovariancePredictors(t)
+
+	p0 := mat.NewSymDense(2, []float64{
+		1, 0.5,
+		0.5, 2,
+	})
+
+	// propagate all implementations with the same call sites
+	results := make([]*mat.SymDense, len(predictors))
+	for i, c := range predictors {
+		p := mat.NewSymDense(2, nil)
+		p.CopySym(p0)
+		for k := 0; k < 50; k++ {
+			p = c.PredictSym(p)
+		}
+		results[i] = p
+	}
+
+	for i := 1; i < len(results); i++ {
+		if !mat.EqualApprox(results[i], results[0], 1e-9) {
+			fmt.Println("received:", mat.Formatted(results[i]))
+			fmt.Println("expected:", mat.Formatted(results[0]))
+			t.Errorf("predictor %d differs from Covariance", i)
+		}
+	}
+}
+
+func TestSqrtCovarianceSingular(t *testing.T) {
+	md := mat.NewDense(2, 2, []float64{
+		1, 1,
+		0, 1,
+	})
+	c, err := NewSqrtCovariance(md, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rank deficient initial covariance
+	p := mat.NewSymDense(2, []float64{
+		1, 1,
+		1, 1,
+	})
+	var next mat.SymDense
+	c.PredictTo(&next, p)
+
+	expected := mat.NewSymDense(2, []float64{
+		4, 2,
+		2, 1,
+	})
+	if !mat.EqualApprox(&next, expected, 1e-9) {
+		fmt.Println("received:", mat.Formatted(&next))
+		fmt.Println("expected:", mat.Formatted(expected))
+		t.Error("predict failed for singular covariance")
+	}
+}
+
+func TestUDFactor(t *testing.T) {
+	p := mat.NewSymDense(3, []float64{
+		4, 2, 1,
+		2, 3, 0.5,
+		1, 0.5, 2,
+	})
+	u, d, err := udFactor(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ud, udut mat.Dense
+	ud.Mul(u, mat.NewDiagDense(3, d))
+	udut.Mul(&ud, u.T())
+	if !mat.EqualApprox(&udut, p, 1e-12) {
+		fmt.Println("received:", mat.Formatted(&udut))
+		fmt.Println("expected:", mat.Formatted(p))
+		t.Error("udFactor failed")
+	}
+}
+
+func TestSqrtCovarianceModified(t *testing.T) {
+	p0 := mat.NewSymDense(2, []float64{
+		1, 0.5,
+		0.5, 2,
+	})
+	indefinite := mat.NewSymDense(2, []float64{
+		1, 2,
+		2, 1,
+	})
+	predictors := newTestCovariancePredictors(t)
+	results := make([]*mat.SymDense, len(predictors))
+	for i, c := range predictors {
+		p := mat.NewSymDense(2, nil)
+		p.CopySym(p0)
+		for k := 0; k < 10; k++ {
+			c.PredictTo(p, p)
+			// measurement update in place
+			p.SetSym(0, 0, 0.5*p.At(0, 0))
+		}
+		// the result of PredictSym is modified in place as well
+		p = c.PredictSym(p)
+		p.SetSym(1, 1, 0.5*p.At(1, 1))
+		p = c.PredictSym(p)
+		// indefinite matrices are propagated without factorization
+		c.PredictTo(p, indefinite)
+		results[i] = p
+	}
+	for i := 1; i < len(results); i++ {
+		if !mat.EqualApprox(results[i], results[0], 1e-9) {
+			fmt.Println("received:", mat.Formatted(results[i]))
+			fmt.Println("expected:", mat.Formatted(results[0]))
+			t.Errorf("predictor %d differs from Covariance after modification", i)
+		}
+	}
+}
+
+func TestSqrtCovarianceUpdate(t *testing.T) {
+	md := mat.NewDense(2, 2, []float64{
+		1, 0.1,
+		0, 0.9,
+	})
+	q := mat.NewSymDense(2, []float64{0.1, 0, 0, 0.2})
+	h := mat.NewDense(2, 2, []float64{
+		1, 0,
+		0.5, 1,
+	})
+	r := mat.NewSymDense(2, []float64{0.5, 0.1, 0.1, 0.3})
+	p0 := mat.NewSymDense(2, []float64{
+		1, 0.5,
+		0.5, 2,
+	})
+
+	// Kalman filter update p - K H p
+	var pht, s, k, khp, expected mat.Dense
+	pht.Mul(p0, h.T())
+	s.Mul(h, &pht)
+	s.Add(&s, r)
+	var si mat.Dense
+	if err := si.Inverse(&s); err != nil {
+		t.Fatal(err)
+	}
+	k.Mul(&pht, &si)
+	khp.Mul(&k, pht.T())
+	expected.Sub(p0, &khp)
+
+	sc, err := NewSqrtCovariance(md, nil, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ud, err := NewUDCovariance(md, nil, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.SetCovariance(p0); err != nil {
+		t.Fatal(err)
+	}
+	if err := ud.SetCovariance(p0); err != nil {
+		t.Fatal(err)
+	}
+	gain, err := sc.UpdateFactor(h, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(gain, &k, 1e-12) || !mat.EqualApprox(sc.Covariance(), &expected, 1e-12) {
+		t.Error("square-root measurement update failed:", mat.Formatted(sc.Covariance()))
+	}
+	gain, err = ud.UpdateFactors(h, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(gain, &k, 1e-12) || !mat.EqualApprox(ud.Covariance(), &expected, 1e-12) {
+		t.Error("UD measurement update failed:", mat.Formatted(ud.Covariance()))
+	}
+
+	// the predicted factors continue from the updated covariance
+	cov, err := NewCovarianceWithNoise(md, nil, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.PredictFactor(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ud.PredictFactors(); err != nil {
+		t.Fatal(err)
+	}
+	next := cov.PredictSym(mat.NewSymDense(2, expected.RawMatrix().Data))
+	if !mat.EqualApprox(sc.Covariance(), next, 1e-12) || !mat.EqualApprox(ud.Covariance(), next, 1e-12) {
+		t.Error("prediction after measurement update failed")
+	}
+
+	if _, err := NewSqrtCovariance(md, nil, mat.NewSymDense(2, []float64{1, 2, 2, 1})); err == nil {
+		t.Error("expected error for indefinite Q")
+	}
+	if _, err := sc.UpdateFactor(h, mat.NewSymDense(1, []float64{1})); err == nil {
+		t.Error("expected error for wrong size of R")
+	}
+}
+
+func TestSqrtCovariancePredictAllocs(t *testing.T) {
+	p := mat.NewSymDense(2, []float64{1, 0, 0, 1})
+	for i, c := range newTestCovariancePredictors(t) {
+		c.PredictTo(p, p)
+		allocs := testing.AllocsPerRun(100, func() {
+			c.PredictTo(p, p)
+		})
+		if allocs != 0 {
+			t.Errorf("predictor %d: PredictTo allocated %v times per run", i, allocs)
+		}
+	}
+
+	// a changed Q is factorized again
+	md := mat.NewDense(2, 2, []float64{1, 0.1, 0, 0.9})
+	q := mat.NewSymDense(2, []float64{1, 0, 0, 1})
+	sqrt, err := NewSqrtCovariance(md, nil, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ud, err := NewUDCovariance(md, nil, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cov, err := NewCovarianceWithNoise(md, nil, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range []CovariancePredictor{sqrt, ud} {
+		q.SetSym(0, 0, 1)
+		p, expected := mat.NewSymDense(2, []float64{1, 0, 0, 1}), mat.NewSymDense(2, []float64{1, 0, 0, 1})
+		c.PredictTo(p, p)
+		cov.PredictTo(expected, expected)
+		q.SetSym(0, 0, 4)
+		c.PredictTo(p, p)
+		cov.PredictTo(expected, expected)
+		if !mat.EqualApprox(p, expected, 1e-12) {
+			t.Errorf("predictor %d ignores the changed Q", i+1)
+		}
+	}
+}
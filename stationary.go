@@ -0,0 +1,98 @@
+package lti
+
+import (
+	"errors"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//StationaryCovariance returns the steady-state covariance P of the state
+//of a stable system driven by white noise w(t) with spectral density Q
+//through the noise input matrix G, x'(t) = A * x(t) + G * w(t).
+//P solves the continuous Lyapunov equation A * P + P * A^T + G * Q * G^T = 0.
+//If G is nil, the noise enters every state directly.
+func (s *System) StationaryCovariance(g *mat.Dense, q mat.Symmetric) (*mat.SymDense, error) {
+	gqgt, err := noiseCovariance(s.A, g, q)
+	if err != nil {
+		return nil, err
+	}
+	return Lyapunov(s.A, gqgt)
+}
+
+//OutputCovariance returns the covariance C * P * C^T + D * R * D^T of the
+//output for the state covariance P and the input noise covariance R.
+//R may be nil.
+func (s *System) OutputCovariance(p, r mat.Symmetric) (*mat.SymDense, error) {
+	return outputCovariance(s.C, s.D, p, r)
+}
+
+//StationaryCovariance returns the steady-state covariance P of the state
+//of a stable discrete system driven by white noise w(k) with covariance Q
+//through the noise input matrix G, x(k+1) = A_d * x(k) + G * w(k).
+//P solves the discrete Lyapunov equation P = A_d * P * A_d^T + G * Q * G^T.
+//If G is nil, the noise enters every state directly.
+func (d *Discrete) StationaryCovariance(g *mat.Dense, q mat.Symmetric) (*mat.SymDense, error) {
+	gqgt, err := noiseCovariance(d.Ad, g, q)
+	if err != nil {
+		return nil, err
+	}
+	return DiscreteLyapunov(d.Ad, gqgt)
+}
+
+//OutputCovariance returns the covariance C * P * C^T + D * R * D^T of the
+//output for the state covariance P and the input noise covariance R.
+//R may be nil.
+func (d *Discrete) OutputCovariance(p, r mat.Symmetric) (*mat.SymDense, error) {
+	return outputCovariance(d.C, d.D, p, r)
+}
+
+// noiseCovariance returns G * Q * G^T after checking the dimensions against a
+func noiseCovariance(a, g *mat.Dense, q mat.Symmetric) (*mat.SymDense, error) {
+	if q == nil {
+		return nil, errors.New("Q should not be nil")
+	}
+	c, err := NewCovarianceWithNoise(a, g, q)
+	if err != nil {
+		return nil, err
+	}
+	if c.G == nil {
+		res := mat.NewSymDense(c.Q.Symmetric(), nil)
+		res.CopySym(c.Q)
+		return res, nil
+	}
+
+	var gq, gqgt mat.Dense
+	gq.Mul(c.G, c.Q)
+	gqgt.Mul(&gq, c.G.T())
+
+	var res mat.SymDense
+	symmetrize(&res, &gqgt)
+	return &res, nil
+}
+
+// outputCovariance returns C * P * C^T + D * R * D^T
+func outputCovariance(c, d *mat.Dense, p, r mat.Symmetric) (*mat.SymDense, error) {
+	_, n := c.Dims()
+	if p.Symmetric() != n {
+		return nil, errors.New("P dim should be equal to C col dim")
+	}
+
+	var cp, cpct mat.Dense
+	cp.Mul(c, p)
+	cpct.Mul(&cp, c.T())
+
+	if r != nil {
+		_, m := d.Dims()
+		if r.Symmetric() != m {
+			return nil, errors.New("R dim should be equal to D col dim")
+		}
+		var dr, drdt mat.Dense
+		dr.Mul(d, r)
+		drdt.Mul(&dr, d.T())
+		cpct.Add(&cpct, &drdt)
+	}
+
+	var res mat.SymDense
+	symmetrize(&res, &cpct)
+	return &res, nil
+}
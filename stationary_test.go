@@ -0,0 +1,85 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSystemStationaryCovariance(t *testing.T) {
+	// first order lag x' = -2 x + w with spectral density q = 3
+	sys, err := NewSystem(
+		mat.NewDense(1, 1, []float64{-2}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{4}),
+		mat.NewDense(1, 1, []float64{1}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := sys.StationaryCovariance(nil, mat.NewSymDense(1, []float64{3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(p.At(0, 0)-0.75) > 1e-12 {
+		fmt.Println("received:", p.At(0, 0))
+		t.Error("StationaryCovariance returned wrong variance")
+	}
+
+	y, err := sys.OutputCovariance(p, mat.NewSymDense(1, []float64{0.5}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(y.At(0, 0)-12.5) > 1e-12 {
+		fmt.Println("received:", y.At(0, 0))
+		t.Error("OutputCovariance returned wrong variance")
+	}
+
+	// double integrator is not stable
+	unstable, _ := NewTestSystem()
+	if _, err := unstable.StationaryCovariance(unstable.B, mat.NewSymDense(1, []float64{1})); err != ErrUnstable {
+		t.Error("expected ErrUnstable, received", err)
+	}
+}
+
+func TestDiscreteStationaryCovariance(t *testing.T) {
+	sys, err := NewSystem(
+		mat.NewDense(2, 2, []float64{0, 1, -2, -3}),
+		mat.NewDense(2, 1, []float64{0, 1}),
+		mat.NewDense(1, 2, []float64{1, 0}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt := 0.001
+	disc, err := sys.Discretize(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the discrete noise covariance approximates Q * dt for small dt
+	q := 2.0
+	pc, err := sys.StationaryCovariance(sys.B, mat.NewSymDense(1, []float64{q}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd, err := disc.StationaryCovariance(sys.B, mat.NewSymDense(1, []float64{q * dt}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.EqualApprox(pc, pd, 2e-3) {
+		fmt.Println("continuous:", mat.Formatted(pc))
+		fmt.Println("discrete:", mat.Formatted(pd))
+		t.Error("StationaryCovariance of discrete system differs from continuous system")
+	}
+
+	// unstable
+	unstable, _ := NewTestDiscrete()
+	if _, err := unstable.StationaryCovariance(nil, mat.NewSymDense(2, []float64{1, 0, 0, 1})); err != ErrUnstable {
+		t.Error("expected ErrUnstable, received", err)
+	}
+}
@@ -0,0 +1,139 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrIntegrating is returned when a system has no unique steady state for a
+// constant input, i.e. a pole at s = 0 (or z = 1 for discrete systems)
+var ErrIntegrating = errors.New("system has a pole at the origin (integrating)")
+
+//DCGain returns the static gain G(0) = -C * A^-1 * B + D of the system
+//or ErrIntegrating if A is singular. Input and output delays do not change
+//the static gain and the steady state.
+func (s *System) DCGain() (*mat.Dense, error) {
+	g, _, err := staticGain(s.A, s.B, s.C, s.D, false)
+	return g, err
+}
+
+//SteadyState returns the steady state x_ss and the input u_ss for which the
+//output settles at the target y. If the target cannot be reached exactly,
+//u_ss is the least-squares solution; if there are more inputs than needed,
+//u_ss is the solution with the smallest norm. Integrating systems
+//return ErrIntegrating.
+func (s *System) SteadyState(y *mat.VecDense) (*mat.VecDense, *mat.VecDense, error) {
+	return steadyState(s.A, s.B, s.C, s.D, y, false)
+}
+
+//DCGain returns the static gain G(1) = C * (I - A_d)^-1 * B_d + D of the
+//discrete system or ErrIntegrating if I - A_d is singular.
+func (d *Discrete) DCGain() (*mat.Dense, error) {
+	g, _, err := staticGain(d.Ad, d.Bd, d.C, d.D, true)
+	return g, err
+}
+
+//SteadyState returns the steady state x_ss = A_d * x_ss + B_d * u_ss and the
+//input u_ss for the target output y, see System.SteadyState.
+func (d *Discrete) SteadyState(y *mat.VecDense) (*mat.VecDense, *mat.VecDense, error) {
+	return steadyState(d.Ad, d.Bd, d.C, d.D, y, true)
+}
+
+// staticGain returns the static gain and the map X = Ae^-1 B from
+// the constant input onto the steady state with
+// Ae = -A for continuous and Ae = I - A_d for discrete systems
+func staticGain(a, b, c, d *mat.Dense, discrete bool) (*mat.Dense, *mat.Dense, error) {
+	n, _ := a.Dims()
+	var ae mat.Dense
+	ae.Scale(-1, a)
+	if discrete {
+		for i := 0; i < n; i++ {
+			ae.Set(i, i, ae.At(i, i)+1)
+		}
+	}
+
+	var lu mat.LU
+	lu.Factorize(&ae)
+	if cond := lu.Cond(); math.IsInf(cond, 1) || cond > 1/(100*machineEpsilon) {
+		return nil, nil, ErrIntegrating
+	}
+	var x mat.Dense
+	if err := lu.SolveTo(&x, false, b); err != nil {
+		return nil, nil, ErrIntegrating
+	}
+
+	var g mat.Dense
+	g.Mul(c, &x)
+	g.Add(&g, d)
+	return &g, &x, nil
+}
+
+// steadyState solves G u = y in the least-squares sense and returns
+// the steady state x = X u and u
+func steadyState(a, b, c, d *mat.Dense, y *mat.VecDense, discrete bool) (*mat.VecDense, *mat.VecDense, error) {
+	if p, _ := c.Dims(); y.Len() != p {
+		return nil, nil, errors.New("target should have the length of the output")
+	}
+	g, x, err := staticGain(a, b, c, d, discrete)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := leastSquares(g, y)
+	if err != nil {
+		return nil, nil, err
+	}
+	var xss mat.VecDense
+	xss.MulVec(x, u)
+	return &xss, u, nil
+}
+
+// leastSquares returns the minimum-norm least-squares solution of a * x = b
+func leastSquares(a mat.Matrix, b *mat.VecDense) (*mat.VecDense, error) {
+	pinv, err := pseudoInverse(a)
+	if err != nil {
+		return nil, err
+	}
+	var x mat.VecDense
+	x.MulVec(pinv, b)
+	return &x, nil
+}
+
+// pseudoInverse returns the Moore-Penrose pseudo inverse V S^+ U^T of a
+func pseudoInverse(a mat.Matrix) (*mat.Dense, error) {
+	var svd mat.SVD
+	if ok := svd.Factorize(a, mat.SVDThin); !ok {
+		return nil, errors.New("pseudoInverse: singular value decomposition failed")
+	}
+	values := svd.Values(nil)
+	var u, v mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+
+	r, c := a.Dims()
+	tol := float64(maxInt(r, c)) * machineEpsilon
+	if len(values) > 0 {
+		tol *= values[0]
+	}
+	for j, sv := range values {
+		f := 0.0
+		if sv > tol {
+			f = 1 / sv
+		}
+		for i := 0; i < c; i++ {
+			v.Set(i, j, v.At(i, j)*f)
+		}
+	}
+	var pinv mat.Dense
+	pinv.Mul(&v, u.T())
+	return &pinv, nil
+}
+
+// maxInt returns the maximum of a and b
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,88 @@
+package lti
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDCGain(t *testing.T) {
+	sys := newTestFourthOrder(t)
+	expected := 1 + 0.5/3 + 0.1/10 + 0.01/50
+
+	g, err := sys.DCGain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(g.At(0, 0)-expected) > 1e-12 {
+		t.Error("DCGain returned wrong gain:", g.At(0, 0))
+	}
+
+	disc, err := sys.Discretize(0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gd, err := disc.DCGain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(gd.At(0, 0)-expected) > 1e-10 {
+		t.Error("discrete DCGain returned wrong gain:", gd.At(0, 0))
+	}
+
+	// integrator
+	integ, _ := NewSystem(
+		mat.NewDense(1, 1, []float64{0}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(1, 1, []float64{0}),
+	)
+	if _, err := integ.DCGain(); err != ErrIntegrating {
+		t.Error("expected ErrIntegrating, received:", err)
+	}
+	if _, _, err := integ.SteadyState(mat.NewVecDense(1, []float64{1})); err != ErrIntegrating {
+		t.Error("expected ErrIntegrating, received:", err)
+	}
+}
+
+func TestSteadyState(t *testing.T) {
+	// two inputs, one output: minimum norm input
+	sum, _ := NewSystem(
+		mat.NewDense(2, 2, []float64{-1, 0, 0, -1}),
+		mat.NewDense(2, 2, []float64{1, 0, 0, 1}),
+		mat.NewDense(1, 2, []float64{2, 1}),
+		mat.NewDense(1, 2, []float64{0, 0}),
+	)
+
+	y := mat.NewVecDense(1, []float64{5})
+	x, u, err := sum.SteadyState(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 u1 + u2 = 5 with minimum norm: u = (2, 1)
+	if !mat.EqualApprox(u, mat.NewVecDense(2, []float64{2, 1}), 1e-10) {
+		fmt.Println("received:", u)
+		t.Error("SteadyState returned wrong input")
+	}
+	var dx mat.VecDense
+	dx.CloneFromVec(sum.Derivative(x, u))
+	if mat.Norm(&dx, 2) > 1e-10 || math.Abs(sum.Response(x, u).AtVec(0)-5) > 1e-10 {
+		t.Error("SteadyState returned no steady state")
+	}
+
+	disc, err := sum.Discretize(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xd, ud, err := disc.SteadyState(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var diff mat.VecDense
+	diff.SubVec(disc.Predict(xd, ud), xd)
+	if mat.Norm(&diff, 2) > 1e-10 || math.Abs(disc.Response(xd, ud).AtVec(0)-5) > 1e-10 {
+		t.Error("discrete SteadyState returned no steady state")
+	}
+}
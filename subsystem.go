@@ -0,0 +1,251 @@
+package lti
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// minimalTolerance is the relative tolerance of the rank decisions in Minimal
+const minimalTolerance = 1e-10
+
+//Subsystem returns the system from the given inputs to the given outputs,
+//i.e. the selected rows of C and D and the selected columns of B and D.
+//If outputs or inputs are nil, all channels are used.
+//The states are not reduced, see Minimal. The delays of the selected
+//channels are kept.
+func (s *System) Subsystem(outputs, inputs []int) (*System, error) {
+	r, err := subsystem(s.realization(), outputs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+//SubsystemByName returns the system from the named inputs to the named outputs,
+//see Subsystem.
+func (s *System) SubsystemByName(outputs, inputs []string) (*System, error) {
+	out, in, err := channelIndices(s.realization(), outputs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return s.Subsystem(out, in)
+}
+
+//Minimal returns a minimal realization of the system without the
+//uncontrollable and unobservable states. The reduced states are
+//orthogonal projections of the original states and are unnamed.
+//Input and output delays are kept.
+func (s *System) Minimal() (*System, error) {
+	r, err := minimal(s.realization())
+	if err != nil {
+		return nil, err
+	}
+	return r.system()
+}
+
+//Subsystem returns the discrete system from the given inputs to the given
+//outputs, see System.Subsystem.
+func (d *Discrete) Subsystem(outputs, inputs []int) (*Discrete, error) {
+	r, err := subsystem(d.realization(), outputs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return r.discrete(d.Dt)
+}
+
+//SubsystemByName returns the discrete system from the named inputs to the
+//named outputs, see System.Subsystem.
+func (d *Discrete) SubsystemByName(outputs, inputs []string) (*Discrete, error) {
+	out, in, err := channelIndices(d.realization(), outputs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return d.Subsystem(out, in)
+}
+
+//Minimal returns a minimal realization of the discrete system,
+//see System.Minimal.
+func (d *Discrete) Minimal() (*Discrete, error) {
+	r, err := minimal(d.realization())
+	if err != nil {
+		return nil, err
+	}
+	return r.discrete(d.Dt)
+}
+
+// subsystem selects the outputs and inputs of r
+func subsystem(r realization, outputs, inputs []int) (realization, error) {
+	n, m, p := r.dims()
+	if outputs == nil {
+		outputs = sequence(p)
+	}
+	if inputs == nil {
+		inputs = sequence(m)
+	}
+	if len(outputs) == 0 || len(inputs) == 0 {
+		return realization{}, errors.New("Subsystem: at least one input and output should be selected")
+	}
+
+	b := mat.NewDense(n, len(inputs), nil)
+	c := mat.NewDense(len(outputs), n, nil)
+	d := mat.NewDense(len(outputs), len(inputs), nil)
+	for j, in := range inputs {
+		if in < 0 || in >= m {
+			return realization{}, errors.New("Subsystem: input out of range")
+		}
+		b.SetCol(j, mat.Col(nil, in, r.b))
+	}
+	for i, out := range outputs {
+		if out < 0 || out >= p {
+			return realization{}, errors.New("Subsystem: output out of range")
+		}
+		c.SetRow(i, mat.Row(nil, out, r.c))
+		for j, in := range inputs {
+			d.Set(i, j, r.d.At(out, in))
+		}
+	}
+
+	var a mat.Dense
+	a.CloneFrom(r.a)
+	return realization{
+		a: &a, b: b, c: c, d: d,
+		inputs:      selectSignals(r.inputs, inputs),
+		outputs:     selectSignals(r.outputs, outputs),
+		states:      copySignals(r.states),
+		inputDelay:  selectDelays(r.inputDelay, inputs),
+		outputDelay: selectDelays(r.outputDelay, outputs),
+	}, nil
+}
+
+// selectDelays returns the delays of the channels idx
+func selectDelays(delays []float64, idx []int) []float64 {
+	if delays == nil {
+		return nil
+	}
+	res := make([]float64, len(idx))
+	for i, ch := range idx {
+		res[i] = delayAt(delays, ch)
+	}
+	return copyDelays(res)
+}
+
+// channelIndices returns the indices of the named outputs and inputs
+func channelIndices(r realization, outputs, inputs []string) ([]int, []int, error) {
+	var out, in []int
+	for _, name := range outputs {
+		idx, err := signalIndex(r.outputs, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, idx)
+	}
+	for _, name := range inputs {
+		idx, err := signalIndex(r.inputs, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		in = append(in, idx)
+	}
+	return out, in, nil
+}
+
+// minimal removes the uncontrollable and the unobservable states of r
+func minimal(r realization) (realization, error) {
+	// controllable subspace: A-invariant and contains range(B),
+	// hence V^T A V, V^T B, C V is the controllable part
+	v := krylovBasis(r.a, r.b)
+	if v == nil {
+		return realization{}, errors.New("Minimal: realization has no controllable states")
+	}
+	var av, a1, b1, c1 mat.Dense
+	av.Mul(r.a, v)
+	a1.Mul(v.T(), &av)
+	b1.Mul(v.T(), r.b)
+	c1.Mul(r.c, v)
+
+	// observable subspace: orthogonal complement of the unobservable,
+	// A-invariant subspace in the kernel of C
+	w := krylovBasis(a1.T(), c1.T())
+	if w == nil {
+		return realization{}, errors.New("Minimal: realization has no observable states")
+	}
+	var aw, a2, b2, c2, d mat.Dense
+	aw.Mul(&a1, w)
+	a2.Mul(w.T(), &aw)
+	b2.Mul(w.T(), &b1)
+	c2.Mul(&c1, w)
+	d.CloneFrom(r.d)
+
+	return realization{
+		a: &a2, b: &b2, c: &c2, d: &d,
+		inputs:      copySignals(r.inputs),
+		outputs:     copySignals(r.outputs),
+		inputDelay:  copyDelays(r.inputDelay),
+		outputDelay: copyDelays(r.outputDelay),
+	}, nil
+}
+
+// krylovBasis returns an orthonormal basis of the Krylov subspace
+// span(B, A B, A^2 B, ...) or nil if the subspace is empty
+func krylovBasis(a, b mat.Matrix) *mat.Dense {
+	n, _ := a.Dims()
+	scale := math.Max(1, math.Max(mat.Norm(a, 2), mat.Norm(b, 2)))
+	tol := minimalTolerance * scale
+
+	var basis *mat.Dense
+	var block mat.Dense
+	block.CloneFrom(b)
+	for k := 0; k < n; k++ {
+		// remove the components in the current basis (twice for stability)
+		if basis != nil {
+			for pass := 0; pass < 2; pass++ {
+				var proj, qqx mat.Dense
+				proj.Mul(basis.T(), &block)
+				qqx.Mul(basis, &proj)
+				block.Sub(&block, &qqx)
+			}
+		}
+		q := orthonormalColumns(&block, tol)
+		if q == nil {
+			break
+		}
+		if basis == nil {
+			basis = q
+		} else {
+			var tmp mat.Dense
+			tmp.Augment(basis, q)
+			basis = &tmp
+		}
+		if _, c := basis.Dims(); c >= n {
+			break
+		}
+		block.Reset()
+		block.Mul(a, q)
+	}
+	return basis
+}
+
+// orthonormalColumns returns an orthonormal basis of the range of m
+// from the left singular vectors with singular values above tol
+func orthonormalColumns(m *mat.Dense, tol float64) *mat.Dense {
+	var svd mat.SVD
+	if ok := svd.Factorize(m, mat.SVDThinU); !ok {
+		return nil
+	}
+	values := svd.Values(nil)
+	r := 0
+	for _, v := range values {
+		if v > tol {
+			r++
+		}
+	}
+	if r == 0 {
+		return nil
+	}
+	var u mat.Dense
+	svd.UTo(&u)
+	rows, _ := u.Dims()
+	return mat.DenseCopyOf(u.Slice(0, rows, 0, r))
+}
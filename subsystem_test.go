@@ -0,0 +1,82 @@
+package lti
+
+import (
+	"fmt"
+	"math/cmplx"
+	"testing"
+)
+
+func TestSubsystemMinimal(t *testing.T) {
+	g1 := newTestFirstOrder(2, 1, 0.5)
+	g2 := newTestFirstOrder(1, 3, 0)
+	g1.Inputs = []Signal{{Name: "valve"}}
+	g1.Outputs = []Signal{{Name: "flow"}}
+	g2.Inputs = []Signal{{Name: "heater"}}
+	g2.Outputs = []Signal{{Name: "temperature"}}
+
+	mimo, err := g1.Append(g2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := mimo.SubsystemByName([]string{"temperature"}, []string{"heater"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := sub.A.Dims(); n != 2 {
+		t.Error("Subsystem should keep all states")
+	}
+	min, err := sub.Minimal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := min.A.Dims(); n != 1 {
+		t.Error("Minimal should remove the states of the other path, received:", n)
+	}
+	if min.Inputs[0].Name != "heater" || min.Outputs[0].Name != "temperature" {
+		t.Error("wrong signals of subsystem")
+	}
+	for _, w := range []float64{0, 1, 10} {
+		if r, e := sisoAt(t, min, w), sisoAt(t, g2, w); cmplx.Abs(r-e) > 1e-12 {
+			fmt.Println("received:", r, "expected:", e)
+			t.Error("wrong response of minimal subsystem")
+		}
+	}
+
+	if _, err := mimo.Subsystem([]int{2}, nil); err == nil {
+		t.Error("expected error for output out of range")
+	}
+	if _, err := mimo.SubsystemByName([]string{"pressure"}, nil); err == nil {
+		t.Error("expected error for unknown output")
+	}
+}
+
+func TestMinimalCancellation(t *testing.T) {
+	// 1 / (s + 1) * (s + 1) / (s + 2) = 1 / (s + 2)
+	sys, err := newTestFirstOrder(1, 1, 0).Series(newTestFirstOrder(-1, 2, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	min, err := sys.Minimal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := min.A.Dims(); n != 1 {
+		t.Fatal("Minimal should cancel the pole at -1, received states:", n)
+	}
+	if p := min.A.At(0, 0); cmplx.Abs(complex(p+2, 0)) > 1e-10 {
+		t.Error("wrong pole of minimal realization:", p)
+	}
+
+	disc, err := sys.Discretize(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dmin, err := disc.Minimal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := dmin.Ad.Dims(); n != 1 {
+		t.Error("discrete Minimal should cancel the pole, received states:", n)
+	}
+}
@@ -14,12 +14,33 @@ import (
 // 	C: Output matrix
 // 	D: Feedforward matrix
 //
+// Inputs, outputs and states can optionally be described by
+// the signals Inputs, Outputs and States.
+//
+// For linearizations, x, u and y are deviations from the operating
+// point X0, U0, Y0 with the derivative Dx0 at the operating point,
+// see SetOperatingPoint.
+//
+// InputDelay and OutputDelay optionally contain the time delays in seconds
+// of every input and output. They are included in the frequency response
+// and in Discretize, and can be approximated by extra states with Pade.
+// Interconnections and reductions keep them where this is exact and
+// return ErrDelays otherwise.
 //
 type System struct {
 	A           *mat.Dense
 	B           *mat.Dense
 	C           *mat.Dense
 	D           *mat.Dense
+	Inputs      []Signal
+	Outputs     []Signal
+	States      []Signal
+	X0          *mat.VecDense
+	U0          *mat.VecDense
+	Y0          *mat.VecDense
+	Dx0         *mat.VecDense
+	InputDelay  []float64
+	OutputDelay []float64
 	ax, bu, sum mat.VecDense // Workspace for multAndSumOp
 }
 
@@ -106,7 +127,27 @@ func (s *System) Observable() (bool, error) {
 	return checkObservability(s.A, s.C)
 }
 
-// Discretize discretizes the time-continuous LTI into an explicit time-discrete LTI system
+// Poles returns the poles of the LTI system, i.e. the eigenvalues of A.
+func (s *System) Poles() ([]complex128, error) {
+	return eigenvalues(s.A)
+}
+
+// Discretize discretizes the time-continuous LTI into an explicit time-discrete LTI system.
+// Delays are discretized exactly for a zero-order hold by shift registers of the
+// delayed samples, see discretizeDelays.
 func (s *System) Discretize(dt float64) (*Discrete, error) {
-	return NewDiscrete(s.A, s.B, s.C, s.D, dt)
+	if s.hasDelays() {
+		return s.discretizeDelays(dt)
+	}
+	d, err := NewDiscrete(s.A, s.B, s.C, s.D, dt)
+	if err != nil {
+		return nil, err
+	}
+	d.Inputs = copySignals(s.Inputs)
+	d.Outputs = copySignals(s.Outputs)
+	d.States = copySignals(s.States)
+	if err := d.discretizeOperatingPoint(s, dt); err != nil {
+		return nil, err
+	}
+	return d, nil
 }
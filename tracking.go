@@ -0,0 +1,113 @@
+package lti
+
+import (
+	"errors"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//TrackingGains contains the reference gains of a state-feedback controller
+//u = -K x + Nbar r for zero steady-state error. Nx and Nu map the reference r
+//onto the steady state x_ss = Nx r and the input u_ss = Nu r, and
+//Nbar = Nu + K Nx.
+type TrackingGains struct {
+	Nx   *mat.Dense
+	Nu   *mat.Dense
+	Nbar *mat.Dense
+}
+
+//TrackingGains returns the reference gains of the system for the
+//state-feedback gain k (inputs x states) by solving
+// 	[A B; C D] [Nx; Nu] = [0; I]
+//in the least-squares sense. An error is returned if the outputs
+//cannot be tracked independently.
+func (s *System) TrackingGains(k *mat.Dense) (*TrackingGains, error) {
+	return trackingGains(s.A, s.B, s.C, s.D, k, false)
+}
+
+//TrackingGains returns the reference gains of the discrete system for the
+//state-feedback gain k by solving
+// 	[A_d - I B_d; C D] [Nx; Nu] = [0; I]
+//see System.TrackingGains.
+func (d *Discrete) TrackingGains(k *mat.Dense) (*TrackingGains, error) {
+	return trackingGains(d.Ad, d.Bd, d.C, d.D, k, true)
+}
+
+//Tracker is a two-degree-of-freedom state-feedback controller
+//u = -K x + Nbar r of a discrete plant.
+type Tracker struct {
+	Plant *Discrete
+	K     *mat.Dense
+	Gains *TrackingGains
+
+	kx, nr, u mat.VecDense // Workspace
+}
+
+//NewTracker returns a Tracker of the plant with the state-feedback gain k
+//and the reference gains of the plant.
+func NewTracker(plant *Discrete, k *mat.Dense) (*Tracker, error) {
+	gains, err := plant.TrackingGains(k)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracker{Plant: plant, K: k, Gains: gains}, nil
+}
+
+//Control returns the input u = -K x + Nbar r for the state x and the reference r.
+//The returned vector is owned by the Tracker and is overwritten by the next call.
+func (t *Tracker) Control(x, r *mat.VecDense) *mat.VecDense {
+	t.kx.MulVec(t.K, x)
+	t.nr.MulVec(t.Gains.Nbar, r)
+	t.u.SubVec(&t.nr, &t.kx)
+	return &t.u
+}
+
+//Step returns the next state x(k+1) = A_d x(k) + B_d u(k) of the plant and
+//the input u(k) = -K x(k) + Nbar r(k). The returned vectors are owned by the
+//Tracker and are overwritten by the next call.
+func (t *Tracker) Step(x, r *mat.VecDense) (*mat.VecDense, *mat.VecDense) {
+	u := t.Control(x, r)
+	return t.Plant.Predict(x, u), u
+}
+
+// trackingGains solves [Ae B; C D] [Nx; Nu] = [0; I] with Ae = A for
+// continuous and Ae = A_d - I for discrete systems
+func trackingGains(a, b, c, d, k *mat.Dense, discrete bool) (*TrackingGains, error) {
+	n, m := b.Dims()
+	p, _ := c.Dims()
+	if kr, kc := k.Dims(); kr != m || kc != n {
+		return nil, errors.New("K should have a row for every input and a column for every state")
+	}
+
+	sys := mat.NewDense(n+p, n+m, nil)
+	setBlock(sys, 0, 0, a)
+	if discrete {
+		for i := 0; i < n; i++ {
+			sys.Set(i, i, sys.At(i, i)-1)
+		}
+	}
+	setBlock(sys, 0, n, b)
+	setBlock(sys, n, 0, c)
+	setBlock(sys, n, n, d)
+	rhs := mat.NewDense(n+p, p, nil)
+	setIdentity(rhs, n, 0, p)
+
+	pinv, err := pseudoInverse(sys)
+	if err != nil {
+		return nil, err
+	}
+	var z, res mat.Dense
+	z.Mul(pinv, rhs)
+	res.Mul(sys, &z)
+	res.Sub(&res, rhs)
+	if mat.Norm(&res, 1) > 1e-8*(1+mat.Norm(sys, 1)) {
+		return nil, errors.New("outputs cannot be tracked with the inputs of the system")
+	}
+
+	nx := mat.DenseCopyOf(z.Slice(0, n, 0, p))
+	nu := mat.DenseCopyOf(z.Slice(n, n+m, 0, p))
+	var nbar mat.Dense
+	nbar.Mul(k, nx)
+	nbar.Add(&nbar, nu)
+	return &TrackingGains{Nx: nx, Nu: nu, Nbar: &nbar}, nil
+}
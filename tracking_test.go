@@ -0,0 +1,58 @@
+package lti
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestTrackingGains(t *testing.T) {
+	sys := newTestFirstOrder(2, 1, 0)
+	gains, err := sys.TrackingGains(mat.NewDense(1, 1, []float64{3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(gains.Nx.At(0, 0)-0.5) > 1e-12 || math.Abs(gains.Nu.At(0, 0)-0.5) > 1e-12 ||
+		math.Abs(gains.Nbar.At(0, 0)-2) > 1e-12 {
+		t.Error("TrackingGains returned wrong gains:", gains.Nx, gains.Nu, gains.Nbar)
+	}
+
+	// two outputs cannot be tracked with one input
+	two, _ := NewSystem(
+		mat.NewDense(1, 1, []float64{-1}),
+		mat.NewDense(1, 1, []float64{1}),
+		mat.NewDense(2, 1, []float64{1, 2}),
+		mat.NewDense(2, 1, []float64{0, 0}),
+	)
+	if _, err := two.TrackingGains(mat.NewDense(1, 1, []float64{1})); err == nil {
+		t.Error("expected error for more outputs than inputs")
+	}
+}
+
+func TestTracker(t *testing.T) {
+	// double integrator, position output
+	plant, err := NewTestDiscrete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := NewTracker(plant, mat.NewDense(1, 2, []float64{10, 5}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := mat.NewVecDense(2, nil)
+	r := mat.NewVecDense(1, []float64{1.5})
+	var u *mat.VecDense
+	for k := 0; k < 300; k++ {
+		var next *mat.VecDense
+		next, u = tracker.Step(x, r)
+		x = mat.VecDenseCopyOf(next)
+	}
+	if y := plant.Response(x, u).AtVec(0); math.Abs(y-1.5) > 1e-8 {
+		t.Error("Tracker has a steady-state error, output:", y)
+	}
+	if math.Abs(u.AtVec(0)) > 1e-8 {
+		t.Error("double integrator should settle with zero input, received:", u.AtVec(0))
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"gonum.org/v1/gonum/mat"
 )
 
+// machineEpsilon is the relative spacing of float64 numbers near 1
+const machineEpsilon = 2.220446049250313e-16
+
 //Discretize
 // A_discretized = exp(A * t)
 func discretize(m *mat.Dense, t float64) (*mat.Dense, error) {
@@ -167,3 +170,32 @@ func multAndSumOp(a *mat.Dense, x *mat.VecDense, b *mat.Dense, u *mat.VecDense,
 
 	return &sum
 }
+
+// eigenvalues returns the eigenvalues of the square matrix a
+func eigenvalues(a *mat.Dense) ([]complex128, error) {
+	if r, c := a.Dims(); r != c {
+		return nil, errors.New("eigenvalues: matrix is not square")
+	}
+	var eig mat.Eigen
+	if ok := eig.Factorize(a, mat.EigenNone); !ok {
+		return nil, errors.New("eigenvalues: factorization failed")
+	}
+	return eig.Values(nil), nil
+}
+
+// realEmbedding returns the real matrix [Re(m) -Im(m); Im(m) Re(m)]
+// which represents the complex matrix m
+func realEmbedding(m *mat.CDense) *mat.Dense {
+	r, c := m.Dims()
+	e := mat.NewDense(2*r, 2*c, nil)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			v := m.At(i, j)
+			e.Set(i, j, real(v))
+			e.Set(r+i, c+j, real(v))
+			e.Set(i, c+j, -imag(v))
+			e.Set(r+i, j, imag(v))
+		}
+	}
+	return e
+}